@@ -0,0 +1,432 @@
+// Package archive unpacks the zip and tar archives downloaded from Nexus.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Format is the archive format of a file
+type Format string
+
+// The supported formats
+const (
+	Zip    Format = "zip"
+	Tar    Format = "tar"
+	TarGz  Format = "tar.gz"
+	TarBz2 Format = "tar.bz2"
+	TarXz  Format = "tar.xz"
+)
+
+var magics = []struct {
+	format Format
+	offset int
+	magic  []byte
+}{
+	{Zip, 0, []byte("PK\x03\x04")},
+	{TarGz, 0, []byte{0x1f, 0x8b}},
+	{TarBz2, 0, []byte("BZh")},
+	{TarXz, 0, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Tar, 257, []byte("ustar")},
+}
+
+// ForPackaging returns the archive format of a Maven packaging, false when files of that packaging
+// are not meant to be unpacked. Jar, war and ear files are zip files but are not archives to unpack.
+func ForPackaging(packaging string) (Format, bool) {
+	switch strings.ToLower(packaging) {
+	case "zip":
+		return Zip, true
+	case "tar":
+		return Tar, true
+	case "tar.gz", "tgz":
+		return TarGz, true
+	case "tar.bz2", "tbz2":
+		return TarBz2, true
+	case "tar.xz", "txz":
+		return TarXz, true
+	}
+	return "", false
+}
+
+// Detect returns the format of an archive from its first bytes
+func Detect(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	header = header[:n]
+	for _, m := range magics {
+		if len(header) >= m.offset+len(m.magic) && bytes.Equal(header[m.offset:m.offset+len(m.magic)], m.magic) {
+			return m.format, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a zip, tar, tar.gz, tar.bz2 or tar.xz archive", path)
+}
+
+// Options tune Extract
+type Options struct {
+	// StripComponents removes that many leading path elements from every entry, like tar --strip-components.
+	// Entries with fewer elements are skipped.
+	StripComponents int
+}
+
+// Extract unpacks an archive into destDir and returns the number of extracted entries.
+// Entries escaping destDir, through their path, a symlink or a hard link, are refused.
+// File modes are kept without the setuid, setgid and sticky bits.
+func Extract(path, destDir string, opts Options) (int, error) {
+	format, err := Detect(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return 0, err
+	}
+	destDir, err = filepath.Abs(destDir)
+	if err != nil {
+		return 0, err
+	}
+	// Containment is checked against real paths, so dest itself is resolved
+	if destDir, err = filepath.EvalSymlinks(destDir); err != nil {
+		return 0, err
+	}
+	x := &extractor{dest: destDir, strip: opts.StripComponents}
+	if format == Zip {
+		err := x.zip(path)
+		return x.count, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var r io.Reader = bufio.NewReader(f)
+	switch format {
+	case TarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		r = gz
+	case TarBz2:
+		r = bzip2.NewReader(r)
+	case TarXz:
+		if r, err = xz.NewReader(r); err != nil {
+			return 0, err
+		}
+	}
+	err = x.tar(r)
+	return x.count, err
+}
+
+// extractor writes the entries of an archive below dest
+type extractor struct {
+	dest  string
+	strip int
+	count int
+	// dirTimes are applied once every entry is written, because writing a file changes the time of its directory
+	dirTimes []dirTime
+}
+
+type dirTime struct {
+	path    string
+	modTime time.Time
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		target, ok, err := x.target(h.Name)
+		if err != nil || !ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		mode := os.FileMode(h.Mode).Perm()
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(target, mode, h.ModTime)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.file(target, tr, mode, h.ModTime)
+		case tar.TypeSymlink:
+			err = x.symlink(target, h.Linkname)
+		case tar.TypeLink:
+			err = x.hardlink(target, h.Linkname)
+		default:
+			// Devices, fifos and extended headers are not extracted
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", h.Name, err)
+		}
+		x.count++
+	}
+	return x.finish()
+}
+
+func (x *extractor) zip(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		target, ok, err := x.target(zf.Name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.dir(target, mode.Perm(), zf.Modified)
+		case mode&os.ModeSymlink != 0:
+			err = x.zipSymlink(target, zf)
+		default:
+			err = x.zipFile(target, zf)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", zf.Name, err)
+		}
+		x.count++
+	}
+	return x.finish()
+}
+
+func (x *extractor) zipFile(target string, zf *zip.File) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	mode := zf.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	return x.file(target, rc, mode, zf.Modified)
+}
+
+func (x *extractor) zipSymlink(target string, zf *zip.File) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	return x.symlink(target, string(b))
+}
+
+// target returns the path of an entry below dest, false when the entry is stripped away,
+// and an error when the entry escapes dest
+func (x *extractor) target(name string) (string, bool, error) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return "", false, fmt.Errorf("refusing the absolute path %s", name)
+	}
+	var parts []string
+	for _, p := range strings.Split(name, "/") {
+		if p == ".." {
+			return "", false, fmt.Errorf("refusing the path %s outside of the destination", name)
+		}
+		if p != "" && p != "." {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) <= x.strip {
+		return "", false, nil
+	}
+	return filepath.Join(x.dest, filepath.Join(parts[x.strip:]...)), true, nil
+}
+
+// inside reports whether a path is dest or below it
+func (x *extractor) inside(path string) bool {
+	rel, err := filepath.Rel(x.dest, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve returns the real path of a path below dest, following the symlinks of its existing part,
+// and an error when that leads outside of dest. Checking the entry names alone is not enough, as a
+// chain of extracted symlinks such as d1 -> . and d1/d2 -> .. reaches the parent of dest.
+func (x *extractor) resolve(path string) (string, error) {
+	existing, rest := path, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			real = filepath.Join(real, rest)
+			if !x.inside(real) {
+				return "", fmt.Errorf("refusing the path %s leading outside of the destination through a symlink", path)
+			}
+			return real, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// parent resolves the directory of a target and creates it
+func (x *extractor) parent(target string) (string, error) {
+	dir, err := x.resolve(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+func (x *extractor) dir(target string, mode os.FileMode, modTime time.Time) error {
+	target, err := x.resolve(target)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	// Keep the directory writable by its owner until every entry is extracted
+	if err := os.Chmod(target, mode|0700); err != nil {
+		return err
+	}
+	x.dirTimes = append(x.dirTimes, dirTime{target, modTime})
+	return nil
+}
+
+func (x *extractor) file(target string, r io.Reader, mode os.FileMode, modTime time.Time) error {
+	dir, err := x.parent(target)
+	if err != nil {
+		return err
+	}
+	target = filepath.Join(dir, filepath.Base(target))
+	// Never write through an existing file, it could be a symlink out of dest
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		return os.Chtimes(target, modTime, modTime)
+	}
+	return nil
+}
+
+func (x *extractor) symlink(target, linkname string) error {
+	if filepath.IsAbs(linkname) || strings.HasPrefix(linkname, "/") {
+		return fmt.Errorf("refusing the symlink to the absolute path %s", linkname)
+	}
+	dir, err := x.parent(target)
+	if err != nil {
+		return err
+	}
+	target = filepath.Join(dir, filepath.Base(target))
+	// Follow the link element by element, as the symlinks it goes through change where .. leads
+	resolved := dir
+	for _, p := range strings.Split(filepath.ToSlash(linkname), "/") {
+		switch p {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+		default:
+			resolved = filepath.Join(resolved, p)
+		}
+		if real, err := filepath.EvalSymlinks(resolved); err == nil {
+			resolved = real
+		}
+	}
+	if !x.inside(resolved) {
+		return fmt.Errorf("refusing the symlink to %s outside of the destination", linkname)
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(linkname, target)
+}
+
+func (x *extractor) hardlink(target, linkname string) error {
+	source, ok, err := x.target(linkname)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("the link target %s is stripped away", linkname)
+	}
+	sourceDir, err := x.resolve(filepath.Dir(source))
+	if err != nil {
+		return err
+	}
+	source = filepath.Join(sourceDir, filepath.Base(source))
+	// The link source must be a regular file that was extracted, not a symlink
+	if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("refusing the hard link to %s", linkname)
+	}
+	dir, err := x.parent(target)
+	if err != nil {
+		return err
+	}
+	target = filepath.Join(dir, filepath.Base(target))
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Link(source, target)
+}
+
+// finish restores the modification time of the directories
+func (x *extractor) finish() error {
+	for i := len(x.dirTimes) - 1; i >= 0; i-- {
+		d := x.dirTimes[i]
+		if !d.modTime.IsZero() {
+			if err := os.Chtimes(d.path, d.modTime, d.modTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+type entry struct {
+	name, body, link string
+	mode             int64
+	typeflag         byte
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []entry) {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: e.mode, Size: int64(len(e.body)), Typeflag: e.typeflag, Linkname: e.link}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestExtractTar(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	entries := []entry{
+		{name: "app-1.0/", mode: 0755, typeflag: tar.TypeDir},
+		{name: "app-1.0/bin/run.sh", body: "#!/bin/sh\n", mode: 0755, typeflag: tar.TypeReg},
+		{name: "app-1.0/lib/app.jar", body: "jar", mode: 0644, typeflag: tar.TypeReg},
+		{name: "app-1.0/run", link: "bin/run.sh", typeflag: tar.TypeSymlink},
+		{name: "app-1.0/app.jar", link: "app-1.0/lib/app.jar", typeflag: tar.TypeLink},
+	}
+	for name, wrap := range map[string]func(io.Writer) io.WriteCloser{
+		"app.tar.gz": func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"app.tar.xz": func(w io.Writer) io.WriteCloser {
+			xw, err := xz.NewWriter(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return xw
+		},
+	} {
+		path := filepath.Join(dir, name)
+		f, _ := os.Create(path)
+		w := wrap(f)
+		writeTar(t, w, entries)
+		w.Close()
+		f.Close()
+
+		dest := filepath.Join(dir, name+".out")
+		n, err := Extract(path, dest, Options{StripComponents: 1})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if n != 4 {
+			t.Errorf("%s: extracted %d entries, want 4", name, n)
+		}
+		info, err := os.Stat(filepath.Join(dest, "bin", "run.sh"))
+		if err != nil || info.Mode().Perm() != 0755 {
+			t.Errorf("%s: got %v, %v", name, info, err)
+		}
+		if link, err := os.Readlink(filepath.Join(dest, "run")); err != nil || link != "bin/run.sh" {
+			t.Errorf("%s: got symlink %q, %v", name, link, err)
+		}
+		if b, _ := ioutil.ReadFile(filepath.Join(dest, "app.jar")); string(b) != "jar" {
+			t.Errorf("%s: got hard link content %q", name, b)
+		}
+	}
+}
+
+func TestExtractRefusesTraversal(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for name, e := range map[string]entry{
+		"dotdot":   {name: "../evil", body: "x", mode: 0644, typeflag: tar.TypeReg},
+		"absolute": {name: "/tmp/evil", body: "x", mode: 0644, typeflag: tar.TypeReg},
+		"symlink":  {name: "link", link: "../../etc", typeflag: tar.TypeSymlink},
+		"abslink":  {name: "link", link: "/etc/passwd", typeflag: tar.TypeSymlink},
+		"hardlink": {name: "link", link: "../secret", typeflag: tar.TypeLink},
+	} {
+		path := filepath.Join(dir, name+".tar")
+		f, _ := os.Create(path)
+		writeTar(t, f, []entry{e})
+		f.Close()
+		if _, err := Extract(path, filepath.Join(dir, name), Options{}); err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("%s: got %v, want a refusal", name, err)
+		}
+	}
+}
+
+func TestExtractRefusesSymlinkChains(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for name, entries := range map[string][]entry{
+		// every link stays inside the destination on its own path, but d2 is really dest/..
+		"chain": {
+			{name: "d1", link: ".", typeflag: tar.TypeSymlink},
+			{name: "d1/d2", link: "..", typeflag: tar.TypeSymlink},
+			{name: "d2/pwned.txt", body: "pwned", mode: 0644, typeflag: tar.TypeReg},
+		},
+		// l only leads outside once x is extracted
+		"late": {
+			{name: "l", link: "x/..", typeflag: tar.TypeSymlink},
+			{name: "x", link: ".", typeflag: tar.TypeSymlink},
+			{name: "l/pwned.txt", body: "pwned", mode: 0644, typeflag: tar.TypeReg},
+		},
+	} {
+		path := filepath.Join(dir, name+".tar")
+		f, _ := os.Create(path)
+		writeTar(t, f, entries)
+		f.Close()
+		if _, err := Extract(path, filepath.Join(dir, name), Options{}); err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("%s: got %v, want a refusal", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "pwned.txt")); err == nil {
+			t.Fatalf("%s: pwned.txt was written outside of the destination", name)
+		}
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "site.zip")
+	f, _ := os.Create(path)
+	zw := zip.NewWriter(f)
+	h := &zip.FileHeader{Name: "site/index.html", Method: zip.Deflate}
+	h.SetMode(0600)
+	w, _ := zw.CreateHeader(h)
+	w.Write([]byte("<html>"))
+	zw.Create("../evil")
+	zw.Close()
+	f.Close()
+
+	_, err := Extract(path, filepath.Join(dir, "out"), Options{})
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("got %v, want a refusal", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "out", "site", "index.html"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("got %v, %v", info, err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "plain.txt")
+	ioutil.WriteFile(path, []byte("not an archive"), 0644)
+	if _, err := Detect(path); err == nil {
+		t.Error("expected an error for a text file")
+	}
+}
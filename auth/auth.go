@@ -0,0 +1,83 @@
+// Package auth provides the ways nexus-cli authenticates its requests to Nexus.
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Authenticator adds credentials to a request before it is sent to Nexus
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Anonymous sends requests without credentials
+type Anonymous struct{}
+
+// Authenticate leaves the request untouched
+func (Anonymous) Authenticate(req *http.Request) error {
+	return nil
+}
+
+// Basic sends a username and password with HTTP basic authentication
+type Basic struct {
+	Username, Password string
+}
+
+// Authenticate sets the basic authentication header
+func (b Basic) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// UserToken sends a Nexus user token. Nexus accepts the name and passcode
+// of a token in place of the username and password of basic authentication.
+type UserToken struct {
+	Name, Passcode string
+}
+
+// Authenticate sets the basic authentication header from the token
+func (t UserToken) Authenticate(req *http.Request) error {
+	if t.Name == "" || t.Passcode == "" {
+		return fmt.Errorf("the user token name and passcode are required")
+	}
+	req.SetBasicAuth(t.Name, t.Passcode)
+	return nil
+}
+
+// Bearer sends a token in a bearer Authorization header
+type Bearer struct {
+	Token string
+}
+
+// Authenticate sets the bearer Authorization header
+func (b Bearer) Authenticate(req *http.Request) error {
+	if b.Token == "" {
+		return fmt.Errorf("the bearer token is empty")
+	}
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+	return nil
+}
+
+// Methods are the names accepted by New
+var Methods = []string{"anonymous", "basic", "netrc", "user-token", "bearer"}
+
+// New returns the Authenticator of a method name.
+// The username and password are the token name and passcode for user-token,
+// and netrcPath is only read for netrc.
+func New(method, username, password, token, netrcPath string) (Authenticator, error) {
+	switch method {
+	case "anonymous":
+		return Anonymous{}, nil
+	case "basic":
+		return Basic{Username: username, Password: password}, nil
+	case "user-token":
+		return UserToken{Name: username, Passcode: password}, nil
+	case "bearer":
+		return Bearer{Token: token}, nil
+	case "netrc":
+		return ReadNetrc(netrcPath)
+	default:
+		return nil, fmt.Errorf("unknown authentication %q, must be one of %v", method, Methods)
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNetrc(t *testing.T) {
+	n := ParseNetrc([]byte(`# company servers
+machine nexus.example.com login deployer password s3cret
+macdef init
+  cd /pub
+
+machine other.example.com
+  login reader
+  account ignored
+  password r3ader
+default login anonymous password guest
+`))
+	for host, want := range map[string]Machine{
+		"nexus.example.com": {"nexus.example.com", "deployer", "s3cret"},
+		"other.example.com": {"other.example.com", "reader", "r3ader"},
+		"unknown.com":       {"", "anonymous", "guest"},
+	} {
+		got, found := n.Lookup(host)
+		if !found || got != want {
+			t.Errorf("Lookup(%s) = %+v, %v, want %+v", host, got, found, want)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "https://nexus.example.com:8443/service/rest/v1/assets", nil)
+	n.Authenticate(req)
+	if username, password, _ := req.BasicAuth(); username != "deployer" || password != "s3cret" {
+		t.Errorf("got %s/%s", username, password)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for method, want := range map[string]string{
+		"anonymous":  "",
+		"basic":      "Basic dXNlcjpwYXNz",
+		"user-token": "Basic dXNlcjpwYXNz",
+		"bearer":     "Bearer tok",
+	} {
+		a, err := New(method, "user", "pass", "tok", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("GET", "http://localhost:8081", nil)
+		if err := a.Authenticate(req); err != nil {
+			t.Fatal(err)
+		}
+		if got := req.Header.Get("Authorization"); got != want {
+			t.Errorf("%s: got %q, want %q", method, got, want)
+		}
+	}
+	if _, err := New("kerberos", "", "", "", ""); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
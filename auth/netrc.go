@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// Netrc sends the login and password of the .netrc machine matching the request host with basic
+// authentication. Requests to hosts without a machine, nor a default entry, are sent anonymously.
+type Netrc struct {
+	Machines []Machine
+}
+
+// Machine is a machine entry of a .netrc file. The default entry has an empty Name.
+type Machine struct {
+	Name, Login, Password string
+}
+
+// DefaultNetrcPath returns $NETRC or the .netrc file of the home directory (_netrc on Windows)
+func DefaultNetrcPath() (string, error) {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc"), nil
+	}
+	return filepath.Join(home, ".netrc"), nil
+}
+
+// ReadNetrc reads a .netrc file, the default one when path is empty
+func ReadNetrc(path string) (*Netrc, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultNetrcPath(); err != nil {
+			return nil, err
+		}
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetrc(b), nil
+}
+
+// ParseNetrc parses the machine, default, login and password tokens of a .netrc file.
+// Macro definitions are skipped.
+func ParseNetrc(b []byte) *Netrc {
+	n := new(Netrc)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	var current *Machine
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// A macro ends with an empty line
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			token := fields[i]
+			if token[0] == '#' {
+				break
+			}
+			next := func() string {
+				if i+1 < len(fields) {
+					i++
+					return fields[i]
+				}
+				return ""
+			}
+			switch token {
+			case "machine":
+				n.Machines = append(n.Machines, Machine{Name: next()})
+				current = &n.Machines[len(n.Machines)-1]
+			case "default":
+				n.Machines = append(n.Machines, Machine{})
+				current = &n.Machines[len(n.Machines)-1]
+			case "login":
+				if value := next(); current != nil {
+					current.Login = value
+				}
+			case "password":
+				if value := next(); current != nil {
+					current.Password = value
+				}
+			case "account":
+				next()
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	return n
+}
+
+// Lookup returns the machine entry of a host, falling back to the default entry
+func (n *Netrc) Lookup(host string) (Machine, bool) {
+	var fallback *Machine
+	for i, m := range n.Machines {
+		if m.Name == host {
+			return m, true
+		}
+		if m.Name == "" && fallback == nil {
+			fallback = &n.Machines[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return Machine{}, false
+}
+
+// Authenticate sets the basic authentication header from the machine of the request host
+func (n *Netrc) Authenticate(req *http.Request) error {
+	if m, found := n.Lookup(req.URL.Hostname()); found {
+		req.SetBasicAuth(m.Login, m.Password)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Package cache stores downloaded artifacts on disk by their sha1 checksum.
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cache is a directory of files named by their sha1:
+// <dir>/objects/<first 2 sha1 characters>/<remaining sha1 characters>.
+// Every object has a .json sidecar with the name it was downloaded as.
+// The modification time of an object is its last use.
+type Cache struct {
+	Dir string
+}
+
+// Entry is a cached file
+type Entry struct {
+	Sha1     string    `json:"sha1" yaml:"sha1"`
+	Name     string    `json:"name" yaml:"name"`
+	Size     int64     `json:"size" yaml:"size"`
+	LastUsed time.Time `json:"lastUsed" yaml:"lastUsed"`
+}
+
+// info is the sidecar of an object
+type info struct {
+	Name  string    `json:"name"`
+	Added time.Time `json:"added"`
+}
+
+// DefaultDir returns the nexus-cli directory of the user cache directory
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nexus-cli"), nil
+}
+
+// New returns the cache of a directory
+func New(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Path returns the path of the object of a sha1
+func (c *Cache) Path(sha1 string) string {
+	sha1 = strings.ToLower(sha1)
+	if len(sha1) < 3 {
+		return filepath.Join(c.Dir, "objects", sha1)
+	}
+	return filepath.Join(c.Dir, "objects", sha1[:2], sha1[2:])
+}
+
+// Copy copies the cached file of a sha1 sum to filePath. The copy is checked against the sha1 and written
+// to a temporary file renamed into place, so filePath never shares its data with the cache. A cached
+// file that does not match its sha1 anymore is removed. Copy reports false when the sha1 is not cached.
+func (c *Cache) Copy(sum, filePath string) (bool, error) {
+	object := c.Path(sum)
+	in, err := os.Open(object)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+	hash := sha1.New()
+	err = writeFile(filePath, 0644, func(w io.Writer) error {
+		if _, err := io.Copy(io.MultiWriter(w, hash), in); err != nil {
+			return err
+		}
+		if !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), sum) {
+			return errCorrupt
+		}
+		return nil
+	})
+	if err == errCorrupt {
+		return false, c.remove(sum)
+	}
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	return true, os.Chtimes(object, now, now)
+}
+
+// errCorrupt is returned by Copy's write when the cached file does not match its sha1
+var errCorrupt = errors.New("the cached file does not match its sha1")
+
+// Put copies a file into the cache under its sha1
+func (c *Cache) Put(sha1, filePath, name string) error {
+	object := c.Path(sha1)
+	if _, err := os.Stat(object); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(info{Name: name, Added: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(object+".json", 0644, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	}); err != nil {
+		return err
+	}
+	// Objects are read-only, they are only ever copied out of the cache
+	return writeFile(object, 0444, func(w io.Writer) error {
+		in, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(w, in)
+		return err
+	})
+}
+
+// writeFile writes a file with a mode through a temporary file of its own renamed into place, so that
+// concurrent writers of the same path never expose or rename a partially written file
+func writeFile(path string, mode os.FileMode, write func(w io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// List returns every cached file, the most recently used first
+func (c *Cache) List() ([]Entry, error) {
+	var entries []Entry
+	root := filepath.Join(c.Dir, "objects")
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == root {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) != "" {
+			return nil
+		}
+		e := Entry{
+			Sha1:     filepath.Base(filepath.Dir(path)) + fi.Name(),
+			Size:     fi.Size(),
+			LastUsed: fi.ModTime(),
+		}
+		if b, err := ioutil.ReadFile(path + ".json"); err == nil {
+			var i info
+			if json.Unmarshal(b, &i) == nil {
+				e.Name = i.Name
+			}
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastUsed.After(entries[j].LastUsed) })
+	return entries, err
+}
+
+// Size returns the total size and the number of the cached files
+func (c *Cache) Size() (int64, int, error) {
+	entries, err := c.List()
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	return size, len(entries), err
+}
+
+// Prune removes the files not used within maxAge, then the least recently used files
+// until the cache is not bigger than maxSize. A zero maxAge or maxSize is no limit.
+// With dryRun set nothing is removed. The removed entries are returned.
+func (c *Cache) Prune(maxAge time.Duration, maxSize int64, dryRun bool) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	var pruned []Entry
+	cutoff := time.Now().Add(-maxAge)
+	// entries are sorted by last use, so the oldest are removed first
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		tooOld := maxAge > 0 && e.LastUsed.Before(cutoff)
+		tooBig := maxSize > 0 && size > maxSize
+		if !tooOld && !tooBig {
+			break
+		}
+		if !dryRun {
+			if err := c.remove(e.Sha1); err != nil {
+				return pruned, err
+			}
+		}
+		size -= e.Size
+		pruned = append(pruned, e)
+	}
+	return pruned, nil
+}
+
+// Verify recomputes the sha1 of every cached file and returns the corrupt ones.
+// With remove set the corrupt files are deleted.
+func (c *Cache) Verify(remove bool) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var corrupt []Entry
+	for _, e := range entries {
+		sum, err := fileSha1(c.Path(e.Sha1))
+		if err != nil {
+			return corrupt, err
+		}
+		if sum == e.Sha1 {
+			continue
+		}
+		corrupt = append(corrupt, e)
+		if remove {
+			if err := c.remove(e.Sha1); err != nil {
+				return corrupt, err
+			}
+		}
+	}
+	return corrupt, nil
+}
+
+func (c *Cache) remove(sha1 string) error {
+	object := c.Path(sha1)
+	os.Remove(object + ".json")
+	return os.Remove(object)
+}
+
+func fileSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// PutResolution saves the resolution metadata of a request key, replacing the previous one
+func (c *Cache) PutResolution(key string, b []byte) error {
+	path := c.resolutionPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, 0644, func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+// Resolution returns the last resolution metadata saved for a request key
+func (c *Cache) Resolution(key string) ([]byte, bool, error) {
+	b, err := ioutil.ReadFile(c.resolutionPath(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	return b, err == nil, err
+}
+
+func (c *Cache) resolutionPath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.Dir, "resolutions", hex.EncodeToString(sum[:])+".json")
+}
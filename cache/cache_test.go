@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(filepath.Join(dir, "cache"))
+
+	files := map[string]string{
+		"foo.jar": "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		"bar.jar": "62cdb7020ff920e5aa642c3d4066950dd1f01f4d",
+	}
+	for name, sha1 := range files {
+		src := filepath.Join(dir, name)
+		ioutil.WriteFile(src, []byte(name[:3]), 0644)
+		if hit, _ := c.Copy(sha1, src); hit {
+			t.Fatalf("%s: unexpected cache hit", name)
+		}
+		if err := c.Put(sha1, src, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := filepath.Join(dir, "copy.jar")
+	hit, err := c.Copy(files["foo.jar"], dest)
+	if err != nil || !hit {
+		t.Fatalf("got %v, %v", hit, err)
+	}
+	if b, _ := ioutil.ReadFile(dest); string(b) != "foo" {
+		t.Errorf("got %q", b)
+	}
+	// The copy does not share its data with the read-only cached file
+	if f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND, 0); err == nil {
+		f.Write([]byte("bar"))
+		f.Close()
+	}
+	if b, _ := ioutil.ReadFile(c.Path(files["foo.jar"])); string(b) != "foo" {
+		t.Errorf("got %q in the cache after changing the copy", b)
+	}
+	if info, err := os.Stat(c.Path(files["foo.jar"])); err != nil || info.Mode().Perm() != 0444 {
+		t.Errorf("got %v, %v for the cached file", info.Mode(), err)
+	}
+
+	size, count, err := c.Size()
+	if err != nil || size != 6 || count != 2 {
+		t.Errorf("got %d bytes, %d files, %v", size, count, err)
+	}
+
+	// Make bar.jar the least recently used and corrupt foo.jar
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(c.Path(files["bar.jar"]), old, old)
+	os.Remove(c.Path(files["foo.jar"]))
+	ioutil.WriteFile(c.Path(files["foo.jar"]), []byte("corrupt"), 0444)
+
+	pruned, err := c.Prune(24*time.Hour, 0, false)
+	if err != nil || len(pruned) != 1 || pruned[0].Name != "bar.jar" {
+		t.Errorf("got %+v, %v", pruned, err)
+	}
+	corrupt, err := c.Verify(true)
+	if err != nil || len(corrupt) != 1 || corrupt[0].Name != "foo.jar" {
+		t.Errorf("got %+v, %v", corrupt, err)
+	}
+	if entries, _ := c.List(); len(entries) != 0 {
+		t.Errorf("got %+v", entries)
+	}
+
+	// A corrupt cached file is a cache miss and is removed
+	c.Put(files["bar.jar"], filepath.Join(dir, "bar.jar"), "bar.jar")
+	os.Remove(c.Path(files["bar.jar"]))
+	ioutil.WriteFile(c.Path(files["bar.jar"]), []byte("corrupt"), 0444)
+	if hit, err := c.Copy(files["bar.jar"], dest); hit || err != nil {
+		t.Errorf("got %v, %v for a corrupt cached file", hit, err)
+	}
+	if b, _ := ioutil.ReadFile(dest); string(b) != "foobar" {
+		t.Errorf("got %q, the destination was changed by a corrupt cached file", b)
+	}
+	if _, err := os.Stat(c.Path(files["bar.jar"])); !os.IsNotExist(err) {
+		t.Errorf("the corrupt cached file was kept: %v", err)
+	}
+
+	if _, found, err := c.Resolution("releases:g:a:LATEST"); found || err != nil {
+		t.Errorf("got %v, %v", found, err)
+	}
+	c.PutResolution("releases:g:a:LATEST", []byte("1"))
+	c.PutResolution("releases:g:a:LATEST", []byte("2"))
+	if b, found, err := c.Resolution("releases:g:a:LATEST"); !found || err != nil || string(b) != "2" {
+		t.Errorf("got %q, %v, %v", b, found, err)
+	}
+}
+
+func TestConcurrentPut(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := New(filepath.Join(dir, "cache"))
+	content := strings.Repeat("artifact", 64*1024)
+	sha1 := "b0e7c7ab51b3b1d3ff81a6e4e07e7ae53b4fc6a1"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		src := filepath.Join(dir, "src"+string(rune('a'+i)))
+		ioutil.WriteFile(src, []byte(content), 0644)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Put(sha1, src, "app.jar"); err != nil {
+				t.Error(err)
+			}
+			c.PutResolution("key", []byte(content))
+		}()
+	}
+	wg.Wait()
+	if b, _ := ioutil.ReadFile(c.Path(sha1)); string(b) != content {
+		t.Errorf("the cached object has %d bytes, want %d", len(b), len(content))
+	}
+	if b, _, _ := c.Resolution("key"); string(b) != content {
+		t.Errorf("the cached resolution has %d bytes, want %d", len(b), len(content))
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(c.Path(sha1)), "*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left: %v", leftovers)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bzon/nexus-cli/cache"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manages the local artifact cache.",
+	Long: `Manages the local artifact cache.
+
+Downloaded artifacts are kept by sha1 in $NEXUS_CLI_CACHE_DIR, or the nexus-cli
+directory of the user cache directory, and are copied and checked from there
+when an artifact resolves to a cached sha1. Use --no-cache to bypass the cache.`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the cached files, the most recently used first.",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := cache.New(cacheDir).List()
+		if err != nil {
+			logger.Errorf("Cache Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&cacheListResult{Dir: cacheDir, Entries: entries})
+	},
+}
+
+// cacheSizeCmd represents the cache size command
+var cacheSizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "Prints the total size of the cache.",
+	Run: func(cmd *cobra.Command, args []string) {
+		size, count, err := cache.New(cacheDir).Size()
+		if err != nil {
+			logger.Errorf("Cache Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&cacheSizeResult{Dir: cacheDir, Size: size, Files: count})
+	},
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Removes the cached files unused for a while or beyond a total size.",
+	Long: `Removes the cached files unused for a while or beyond a total size.
+
+Files not used within --older-than are removed first, then the least recently
+used files until the cache fits in --max-size. For example:
+nexus-cli cache prune --older-than 30d --max-size 10GB`,
+	Run: func(cmd *cobra.Command, args []string) {
+		maxAge, err := parseAge(pruneOlderThan)
+		if err != nil {
+			logger.Errorf("Error: --older-than: %v", err)
+			os.Exit(1)
+		}
+		maxSize, err := parseSize(pruneMaxSize)
+		if err != nil {
+			logger.Errorf("Error: --max-size: %v", err)
+			os.Exit(1)
+		}
+		if maxAge == 0 && maxSize == 0 {
+			logger.Errorf("Error: set --older-than or --max-size")
+			os.Exit(1)
+		}
+		pruned, err := cache.New(cacheDir).Prune(maxAge, maxSize, pruneDryRun)
+		result := &cachePruneResult{Entries: pruned, DryRun: pruneDryRun}
+		for _, e := range pruned {
+			result.Size += e.Size
+		}
+		printResult(result)
+		if err != nil {
+			logger.Errorf("Cache Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// cacheVerifyCmd represents the cache verify command
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks the sha1 of every cached file.",
+	Long: `Checks the sha1 of every cached file.
+
+The exit code is non-zero when a file is corrupt. Use --remove to delete the
+corrupt files so that they are downloaded again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		corrupt, err := cache.New(cacheDir).Verify(verifyRemove)
+		if err != nil {
+			logger.Errorf("Cache Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&cacheVerifyResult{Corrupt: corrupt, Removed: verifyRemove})
+		if len(corrupt) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// initCache hands the artifact cache to the nexus package unless --no-cache is set and turns on --offline
+func initCache() error {
+	if cacheDir == "" {
+		cacheDir = os.Getenv("NEXUS_CLI_CACHE_DIR")
+	}
+	if cacheDir == "" {
+		dir, err := cache.DefaultDir()
+		if err != nil {
+			return err
+		}
+		cacheDir = dir
+	}
+	if offline && noCache {
+		return fmt.Errorf("--offline needs the cache, it cannot be used with --no-cache")
+	}
+	if !noCache {
+		nexus.Cache = cache.New(cacheDir)
+	}
+	nexus.Offline = offline
+	return nil
+}
+
+// parseAge parses a duration that also accepts days, for example 30d
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseSize parses a size in bytes with an optional K, M, G or T (1024 based) unit, for example 10GB
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	multiplier := int64(1)
+	for i, unit := range "KMGT" {
+		if strings.HasSuffix(number, string(unit)) {
+			number = strings.TrimSuffix(number, string(unit))
+			multiplier = int64(1) << (10 * uint(i+1))
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatSize formats a size in bytes with a 1024 based unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+// cacheListResult is the result of the cache list command
+type cacheListResult struct {
+	Dir     string        `json:"dir" yaml:"dir"`
+	Entries []cache.Entry `json:"entries" yaml:"entries"`
+}
+
+func (r *cacheListResult) printText(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SHA1\tSIZE\tLAST USED\tNAME")
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Sha1, formatSize(e.Size), e.LastUsed.Format("2006-01-02 15:04"), e.Name)
+	}
+	w.Flush()
+}
+
+// cacheSizeResult is the result of the cache size command
+type cacheSizeResult struct {
+	Dir   string `json:"dir" yaml:"dir"`
+	Size  int64  `json:"size" yaml:"size"`
+	Files int    `json:"files" yaml:"files"`
+}
+
+func (r *cacheSizeResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "%s in %d files in %s\n", formatSize(r.Size), r.Files, r.Dir)
+}
+
+// cachePruneResult is the result of the cache prune command
+type cachePruneResult struct {
+	Entries []cache.Entry `json:"entries" yaml:"entries"`
+	Size    int64         `json:"size" yaml:"size"`
+	DryRun  bool          `json:"dryRun" yaml:"dryRun"`
+}
+
+func (r *cachePruneResult) printText(w io.Writer) {
+	verb := "Removed"
+	if r.DryRun {
+		verb = "Would remove"
+	}
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%s %s %s\n", verb, e.Sha1, e.Name)
+	}
+	fmt.Fprintf(w, "%s %d files, %s\n", verb, len(r.Entries), formatSize(r.Size))
+}
+
+// cacheVerifyResult is the result of the cache verify command
+type cacheVerifyResult struct {
+	Corrupt []cache.Entry `json:"corrupt" yaml:"corrupt"`
+	Removed bool          `json:"removed" yaml:"removed"`
+}
+
+func (r *cacheVerifyResult) printText(w io.Writer) {
+	for _, e := range r.Corrupt {
+		if r.Removed {
+			fmt.Fprintf(w, "Removed corrupt %s %s\n", e.Sha1, e.Name)
+		} else {
+			fmt.Fprintf(w, "Corrupt %s %s\n", e.Sha1, e.Name)
+		}
+	}
+	if len(r.Corrupt) == 0 {
+		fmt.Fprintln(w, "Every cached file is intact")
+	}
+}
+
+var cacheDir string
+var noCache bool
+
+// offline is the --offline flag
+var offline bool
+var pruneOlderThan, pruneMaxSize string
+var pruneDryRun, verifyRemove bool
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "The artifact cache directory. Defaults to Env $NEXUS_CLI_CACHE_DIR or the nexus-cli directory of the user cache directory.")
+	RootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Always download artifacts instead of using the cache.")
+	RootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Never contact Nexus: resolve artifacts from the resolutions and files of the cache.")
+
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheSizeCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	cacheCmd.AddCommand(cacheVerifyCmd)
+	cachePruneCmd.PersistentFlags().StringVar(&pruneOlderThan, "older-than", "", "Remove the files not used within this duration. Example: 30d or 12h")
+	cachePruneCmd.PersistentFlags().StringVar(&pruneMaxSize, "max-size", "", "Remove the least recently used files until the cache fits in this size. Example: 10GB")
+	cachePruneCmd.PersistentFlags().BoolVar(&pruneDryRun, "dry-run", false, "Only print the files that would be removed.")
+	cacheVerifyCmd.PersistentFlags().BoolVar(&verifyRemove, "remove", false, "Remove the corrupt files.")
+}
@@ -0,0 +1,142 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manages the server profiles of the config file.",
+	Long: `Manages the server profiles of the config file.
+
+A profile holds the host, credentials, default repository and TLS settings of a
+Nexus server. Server flags and environment variables override the profile.`,
+}
+
+// configUseProfileCmd represents the config use-profile command
+var configUseProfileCmd = &cobra.Command{
+	Use:   "use-profile NAME",
+	Short: "Sets the current-profile of the config file.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if viper.ConfigFileUsed() == "" {
+			logger.Errorf("Error: no config file found, create $HOME/.nexuscli.yaml or use --config")
+			os.Exit(1)
+		}
+		profiles, err := readProfiles()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		name := strings.ToLower(args[0])
+		if _, found := profiles[name]; !found {
+			logger.Errorf("Error: profile %q is not defined in %s", args[0], viper.ConfigFileUsed())
+			os.Exit(1)
+		}
+		viper.Set("current-profile", name)
+		if err := viper.WriteConfig(); err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&currentProfileResult{Name: name, Profile: profiles[name]})
+	},
+}
+
+// configGetProfilesCmd represents the config get-profiles command
+var configGetProfilesCmd = &cobra.Command{
+	Use:   "get-profiles",
+	Short: "Lists the profiles of the config file.",
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := readProfiles()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		result := &profilesResult{Current: activeProfile}
+		for _, name := range profileNames(profiles) {
+			result.Profiles = append(result.Profiles, currentProfileResult{Name: name, Profile: profiles[name]})
+		}
+		printResult(result)
+	},
+}
+
+// configCurrentProfileCmd represents the config current-profile command
+var configCurrentProfileCmd = &cobra.Command{
+	Use:   "current-profile",
+	Short: "Prints the profile in use.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if activeProfile == "" {
+			logger.Errorf("Error: no profile selected, use --profile, $NEXUS_PROFILE or 'nexus-cli config use-profile'")
+			os.Exit(1)
+		}
+		printResult(&currentProfileResult{Name: activeProfile, Profile: profile})
+	},
+}
+
+// currentProfileResult is a single named profile
+type currentProfileResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Profile `yaml:",inline"`
+}
+
+func (r *currentProfileResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "%s (%s)\n", r.Name, r.Host)
+}
+
+// profilesResult is the result of the config get-profiles command
+type profilesResult struct {
+	Current  string                 `json:"current,omitempty" yaml:"current,omitempty"`
+	Profiles []currentProfileResult `json:"profiles" yaml:"profiles"`
+}
+
+func (r *profilesResult) printText(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tHOST\tVERSION\tREPOSITORY")
+	for _, p := range r.Profiles {
+		current := ""
+		if p.Name == r.Current {
+			current = "*"
+		}
+		version := "-"
+		if p.Version != 0 {
+			version = fmt.Sprint(p.Version)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", current, p.Name, p.Host, version, p.Repository)
+	}
+	w.Flush()
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configUseProfileCmd)
+	configCmd.AddCommand(configGetProfilesCmd)
+	configCmd.AddCommand(configCurrentProfileCmd)
+}
@@ -21,10 +21,12 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"time"
 
-	"github.com/bzon/nexus-cli/nexus2"
+	"github.com/bzon/nexus-cli/archive"
+	"github.com/bzon/nexus-cli/manifest"
+	"github.com/bzon/nexus-cli/nexus"
 	"github.com/spf13/cobra"
 )
 
@@ -35,30 +37,74 @@ var downloadCmd = &cobra.Command{
 	Long: `Downloads a single artifact from Nexus.
 
 Specify the GAVP [-g, -a, -v, -p] flags. For example:
-nexus-cli download -H http://localhost:8087 --group com.examplegroup --artifact myartifact --version 1.0.0 --packging jar --destination /tmp/`,
+nexus-cli download -H http://localhost:8087 --group com.examplegroup --artifact myartifact --version 1.0.0 --packging jar --destination /tmp/
+
+Archives are unpacked after the checksum verification with --extract. For example:
+nexus-cli download -g com.examplegroup -a myapp -p tar.gz --extract /opt/myapp --strip-components 1 --remove-archive`,
 	Run: func(cmd *cobra.Command, args []string) {
-		artifact.HostURL = NexusHostURL
-		artifact.Username = NexusUsername
-		artifact.Password = NexusPassword
-		_, err := nexus2.DownloadArtifact(artifact)
+		requireServer()
+		checkLayout(cmd, &artifact.Dir)
+		if artifact.Repository == "" {
+			artifact.Repository = profile.Repository
+		}
+		entry := manifest.Entry{
+			Group:      artifact.Group,
+			Artifact:   artifact.Artifact,
+			Version:    artifact.Version,
+			Packaging:  artifact.Extension,
+			Repository: artifact.Repository,
+		}
+		start := time.Now()
+		locked, filePath, err := downloadEntry(requireClient(), entry, artifact, nil)
+		result := newArtifactResult(entry.String(), locked, filePath, start, err)
+		if err == nil && extractDir != "" {
+			if err = extract(&result); err != nil {
+				result.Status, result.Error = statusFailed, err.Error()
+			}
+		}
+		printResult(result)
 		if err != nil {
-			fmt.Printf("Download Error: %v", err)
+			logger.Errorf("Download Error: %v", err)
 			os.Exit(1)
 		}
 	},
 }
 
-var artifact nexus2.ArtifactRequest
+// extract unpacks the downloaded archive of a result into --extract and removes it with --remove-archive
+func extract(result *artifactResult) error {
+	logger.Infof("Extracting %s to %s", result.File, extractDir)
+	n, err := archive.Extract(result.File, extractDir, archive.Options{StripComponents: stripComponents})
+	if err != nil {
+		return err
+	}
+	result.Extracted, result.ExtractedEntries = extractDir, n
+	if removeArchive {
+		if err := os.Remove(result.File); err != nil {
+			return err
+		}
+		result.ArchiveRemoved = true
+	}
+	return nil
+}
+
+var artifact nexus.Request
+var extractDir string
+var stripComponents int
+var removeArchive bool
 
 func init() {
 	RootCmd.AddCommand(downloadCmd)
-	downloadCmd.PersistentFlags().StringVarP(&artifact.RepositoryID, "repository", "r", "", "The Nexus repository id. Example: 'releases' or 'snapshots'")
-	downloadCmd.PersistentFlags().StringVarP(&artifact.GroupID, "group", "g", "", "The artifact group id.")
+	downloadCmd.PersistentFlags().StringVarP(&artifact.Repository, "repository", "r", "", "The Nexus repository id. Example: 'releases' or 'snapshots'")
+	downloadCmd.PersistentFlags().StringVarP(&artifact.Group, "group", "g", "", "The artifact group id.")
 	downloadCmd.PersistentFlags().StringVarP(&artifact.Artifact, "artifact", "a", "", "The artifact id.")
-	downloadCmd.PersistentFlags().StringVarP(&artifact.Packaging, "packaging", "p", "", "The artifact packaging. Example: jar, war, zip, or tar, etc.")
+	downloadCmd.PersistentFlags().StringVarP(&artifact.Extension, "packaging", "p", "", "The artifact packaging. Example: jar, war, zip, or tar, etc.")
 	downloadCmd.PersistentFlags().StringVarP(&artifact.Version, "version", "v", "LATEST", "The artifact version.")
 	cwd, _ := os.Getwd()
-	downloadCmd.PersistentFlags().StringVarP(&artifact.DestinationDir, "destination", "d", cwd, "The directory where to place the file.")
+	downloadCmd.PersistentFlags().StringVarP(&artifact.Dir, "destination", "d", cwd, "The directory where to place the file.")
+	downloadCmd.PersistentFlags().StringVar(&layout, "layout", "flat", "Place the file flat in the destination, or in the Maven repository layout with 'maven'. The maven layout defaults the destination to ~/.m2/repository.")
+	downloadCmd.PersistentFlags().StringVar(&extractDir, "extract", "", "Unpack the downloaded zip, tar, tar.gz, tar.bz2 or tar.xz archive into this directory.")
+	downloadCmd.PersistentFlags().IntVar(&stripComponents, "strip-components", 0, "Remove this many leading path elements from the extracted entries.")
+	downloadCmd.PersistentFlags().BoolVar(&removeArchive, "remove-archive", false, "Delete the archive once it is extracted.")
 	downloadCmd.MarkPersistentFlagRequired("group")
 	downloadCmd.MarkPersistentFlagRequired("artifact")
 	downloadCmd.MarkPersistentFlagRequired("packaging")
@@ -0,0 +1,249 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bzon/nexus-cli/archive"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/release"
+	"github.com/spf13/cobra"
+)
+
+// installCmd represents the install command
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Installs an artifact as a versioned release and switches the current symlink to it.",
+	Long: `Installs an artifact as a versioned release and switches the current symlink to it.
+
+The artifact is downloaded, unpacked when its packaging is zip, tar, tar.gz,
+tgz, tar.bz2 or tar.xz, and placed in <base>/releases/<version>. Jar, war and
+ear files are placed as they are. Then <base>/current is switched atomically to it.
+The --pre-hook command runs before the release is downloaded and aborts the
+install when it fails. The --post-hook command runs after the switch, for example to restart a
+service. Hooks run with sh -c in the base directory with these variables:
+NEXUS_GROUP, NEXUS_ARTIFACT, NEXUS_VERSION, NEXUS_SHA1, NEXUS_RELEASE_DIR,
+NEXUS_PREVIOUS_VERSION and NEXUS_BASE_DIR.
+Only the --keep most recently active releases are kept. For example:
+nexus-cli install -g com.example -a myservice -p tar.gz --base /opt/myservice --strip-components 1 --post-hook 'systemctl restart myservice'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		if installRequest.Repository == "" {
+			installRequest.Repository = profile.Repository
+		}
+		base := release.Base{Dir: installBase}
+
+		result, err := install(requireClient(), base, installRequest)
+		if result != nil {
+			printResult(result)
+		}
+		if err != nil {
+			logger.Errorf("Install Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// installRollbackCmd represents the install rollback command
+var installRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Switches the current symlink back to the previously active release.",
+	Long: `Switches the current symlink back to the previously active release.
+
+The --pre-hook and --post-hook commands run like for install. For example:
+nexus-cli install rollback --base /opt/myservice --post-hook 'systemctl restart myservice'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		base := release.Base{Dir: installBase}
+		current, err := base.Current()
+		if err != nil {
+			logger.Errorf("Rollback Error: %v", err)
+			os.Exit(1)
+		}
+		previous, err := base.Previous()
+		if err != nil {
+			logger.Errorf("Rollback Error: %v", err)
+			os.Exit(1)
+		}
+		env := hookEnv(base, previous, current)
+		if err := base.RunHook(preHook, env); err != nil {
+			logger.Errorf("Rollback Error: %v", err)
+			os.Exit(1)
+		}
+		if err := base.Activate(previous.Version); err != nil {
+			logger.Errorf("Rollback Error: %v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Switched %s to %s", filepath.Join(installBase, "current"), previous.Version)
+		result := &installResult{Coordinates: previous.Coordinates, Version: previous.Version, ReleaseDir: base.ReleaseDir(previous.Version), Previous: current}
+		err = base.RunHook(postHook, env)
+		printResult(result)
+		if err != nil {
+			logger.Errorf("Rollback Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// install downloads, unpacks and activates a release. The result is not nil once the release is active.
+func install(client nexus.Client, base release.Base, req nexus.Request) (*installResult, error) {
+	res, err := nexus.ResolveArtifact(client, req)
+	if err != nil {
+		return nil, err
+	}
+	r := release.Release{
+		Version:     res.Version,
+		Group:       res.Group,
+		Artifact:    res.Artifact,
+		Coordinates: coordinates(req),
+		Sha1:        res.Sha1,
+		InstalledAt: time.Now(),
+	}
+	if err := release.CheckVersion(r.Version); err != nil {
+		return nil, err
+	}
+	previous, err := base.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := base.Installed(r.Version)
+	if installed && installForce && r.Version == previous {
+		return nil, fmt.Errorf("release %s is current, switch to another release with 'nexus-cli install rollback' before installing it again with --force", r.Version)
+	}
+
+	// The pre-hook runs before anything below releases/ is touched
+	env := hookEnv(base, r, previous)
+	if err := base.RunHook(preHook, env); err != nil {
+		return nil, err
+	}
+	if installed && !installForce {
+		logger.Infof("Release %s is already installed in %s", r.Version, base.ReleaseDir(r.Version))
+	} else if err := stageRelease(client, base, req, res, r); err != nil {
+		return nil, err
+	}
+	if err := base.Activate(r.Version); err != nil {
+		return nil, err
+	}
+	logger.Infof("Switched %s to %s", filepath.Join(base.Dir, "current"), r.Version)
+	result := &installResult{Coordinates: r.Coordinates, Version: r.Version, ReleaseDir: base.ReleaseDir(r.Version), Previous: previous}
+	if err := base.RunHook(postHook, env); err != nil {
+		return result, fmt.Errorf("%v, run 'nexus-cli install rollback' to switch back to %s", err, previous)
+	}
+	if result.Pruned, err = base.Prune(installKeep); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// stageRelease downloads the artifact into a staging directory, unpacks it when its packaging is an archive
+// and commits the staging directory as the release
+func stageRelease(client nexus.Client, base release.Base, req nexus.Request, res *nexus.Resolution, r release.Release) error {
+	staging, err := base.Stage(r.Version)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	downloadDir := staging + ".download"
+	if err := os.MkdirAll(downloadDir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(downloadDir)
+	req.Dir = downloadDir
+	filePath, err := nexus.DownloadResolved(client, req, res)
+	if err != nil {
+		return err
+	}
+
+	unpacked, err := release.Place(filePath, staging, req.Extension, archive.Options{StripComponents: installStripComponents})
+	if err != nil {
+		return err
+	}
+	if unpacked {
+		logger.Infof("Extracted %s", filepath.Base(filePath))
+	}
+	return base.Commit(staging, r)
+}
+
+// hookEnv returns the environment variables of the hooks
+func hookEnv(base release.Base, r release.Release, previous string) map[string]string {
+	env := map[string]string{
+		"NEXUS_GROUP":            r.Group,
+		"NEXUS_ARTIFACT":         r.Artifact,
+		"NEXUS_VERSION":          r.Version,
+		"NEXUS_SHA1":             r.Sha1,
+		"NEXUS_RELEASE_DIR":      base.ReleaseDir(r.Version),
+		"NEXUS_PREVIOUS_VERSION": previous,
+		"NEXUS_BASE_DIR":         base.Dir,
+	}
+	return env
+}
+
+// installResult is the result of the install and install rollback commands
+type installResult struct {
+	Coordinates string   `json:"coordinates" yaml:"coordinates"`
+	Version     string   `json:"version" yaml:"version"`
+	ReleaseDir  string   `json:"releaseDir" yaml:"releaseDir"`
+	Previous    string   `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Pruned      []string `json:"pruned,omitempty" yaml:"pruned,omitempty"`
+}
+
+func (r *installResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "%s %s is current: %s\n", r.Coordinates, r.Version, r.ReleaseDir)
+	if r.Previous != "" && r.Previous != r.Version {
+		fmt.Fprintf(w, "Previous release: %s\n", r.Previous)
+	}
+	for _, version := range r.Pruned {
+		fmt.Fprintf(w, "Removed release %s\n", version)
+	}
+}
+
+var installRequest nexus.Request
+var installBase, preHook, postHook string
+var installKeep, installStripComponents int
+var installForce bool
+
+func init() {
+	RootCmd.AddCommand(installCmd)
+	installCmd.AddCommand(installRollbackCmd)
+	installCmd.Flags().StringVarP(&installRequest.Repository, "repository", "r", "", "The Nexus repository id. Example: 'releases' or 'snapshots'")
+	installCmd.Flags().StringVarP(&installRequest.Group, "group", "g", "", "The artifact group id.")
+	installCmd.Flags().StringVarP(&installRequest.Artifact, "artifact", "a", "", "The artifact id.")
+	installCmd.Flags().StringVarP(&installRequest.Extension, "packaging", "p", "", "The artifact packaging. Example: jar, war, zip, or tar.gz, etc.")
+	installCmd.Flags().StringVarP(&installRequest.Version, "version", "v", "LATEST", "The artifact version.")
+	installCmd.Flags().StringVarP(&installRequest.Classifier, "classifier", "c", "", "The artifact classifier.")
+	installCmd.Flags().IntVar(&installStripComponents, "strip-components", 0, "Remove this many leading path elements from the extracted entries.")
+	installCmd.Flags().IntVar(&installKeep, "keep", 5, "The number of releases to keep, the current one included.")
+	installCmd.Flags().BoolVar(&installForce, "force", false, "Download and unpack the version again when it is already installed. The current release cannot be reinstalled.")
+	installCmd.MarkFlagRequired("group")
+	installCmd.MarkFlagRequired("artifact")
+	installCmd.MarkFlagRequired("packaging")
+
+	installCmd.PersistentFlags().StringVar(&installBase, "base", "", "The base directory holding releases/ and the current symlink.")
+	installCmd.PersistentFlags().StringVar(&preHook, "pre-hook", "", "The command to run before installing or switching the release.")
+	installCmd.PersistentFlags().StringVar(&postHook, "post-hook", "", "The command to run after switching the current symlink.")
+	installCmd.MarkPersistentFlagRequired("base")
+}
@@ -0,0 +1,102 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bzon/nexus-cli/manifest"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Manages the lock files of multi-download.",
+}
+
+// lockUpdateCmd represents the lock update command
+var lockUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Resolves every artifact of a multi-download file again and rewrites its lock file.",
+	Long: `Resolves every artifact of a multi-download file again and rewrites its lock file.
+
+Nothing is downloaded. For example:
+nexus-cli lock update -H http://localhost:8087 -f artifacts.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := loadManifest(lockManifest)
+		if err != nil {
+			logger.Errorf("ERROR: %v", err)
+			os.Exit(1)
+		}
+		applyManifestServer(m.Server)
+		requireServer()
+		client := requireClient()
+		base := nexus.Request{Repository: profile.Repository}
+
+		var lock manifest.Lock
+		for _, entry := range m.Artifacts {
+			r := entry.Request(base)
+			res, err := nexus.ResolveArtifact(client, r)
+			if err != nil {
+				logger.Errorf("ERROR: %s:%d: %s: %v", lockManifest, entry.Line, entry, err)
+				os.Exit(1)
+			}
+			lock.Artifacts = append(lock.Artifacts, manifest.NewLockedArtifact(entry, r, res))
+		}
+		if lockUpdateFile == "" {
+			lockUpdateFile = manifest.LockPath(lockManifest)
+		}
+		if err := lock.Write(lockUpdateFile); err != nil {
+			logger.Errorf("ERROR: %v", err)
+			os.Exit(1)
+		}
+		printResult(&lockUpdateResult{LockFile: lockUpdateFile, Artifacts: lock.Artifacts})
+	},
+}
+
+// lockUpdateResult is the result of the lock update command
+type lockUpdateResult struct {
+	LockFile  string                    `json:"lockFile" yaml:"lockFile"`
+	Artifacts []manifest.LockedArtifact `json:"artifacts" yaml:"artifacts"`
+}
+
+func (r *lockUpdateResult) printText(w io.Writer) {
+	for _, a := range r.Artifacts {
+		fmt.Fprintf(w, "Locked %s to %s (sha1 %s)\n", a.Coordinates, a.Version, a.Sha1)
+	}
+	fmt.Fprintln(w, "Wrote lock file", r.LockFile)
+}
+
+var lockManifest, lockUpdateFile string
+
+func init() {
+	RootCmd.AddCommand(lockCmd)
+	lockCmd.AddCommand(lockUpdateCmd)
+	lockUpdateCmd.PersistentFlags().StringVarP(&lockManifest, "file", "f", "", "The artifacts file.")
+	lockUpdateCmd.PersistentFlags().StringVar(&lockUpdateFile, "lock-file", "", "The lock file. Defaults to the artifacts file with a '.lock' extension.")
+	lockUpdateCmd.PersistentFlags().StringSliceVar(&manifestScopes, "scope", nil, "Only lock the pom.xml dependencies in these Maven scopes. Example: compile,runtime")
+	lockUpdateCmd.PersistentFlags().StringSliceVar(&manifestConfigurations, "configuration", nil, "Only lock the gradle.lockfile dependencies of these Gradle configurations. Example: runtimeClasspath")
+	lockUpdateCmd.MarkPersistentFlagRequired("file")
+}
@@ -0,0 +1,246 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/credentials"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Verifies credentials against Nexus and saves them encrypted.",
+	Long: `Verifies credentials against Nexus and saves them encrypted.
+
+The username and password are prompted for when they are not given, so that
+they do not end up in the shell history. The credentials are encrypted with the
+passphrase of $NEXUS_CLI_PASSPHRASE, or the content of the key file named by
+$NEXUS_CLI_KEY_FILE, and every command uses them for the same host url.
+For example:
+export NEXUS_CLI_KEY_FILE=~/.config/nexus-cli/key
+nexus-cli login -H https://nexus.example.com
+echo "$PASSWORD" | nexus-cli login -H https://nexus.example.com -U deployer --password-stdin`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if NexusHostURL == "" {
+			logger.Errorf(`Error: required flag(s) "hostURL" not set`)
+			os.Exit(1)
+		}
+		store, err := openCredentials()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		username, password, err := promptCredentials()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		method := authMethod
+		if method == "" {
+			method = profile.Auth
+		}
+		if method == "" {
+			method = "basic"
+		}
+		if method != "basic" && method != "user-token" {
+			logger.Errorf("Error: login only saves basic or user-token credentials, got --auth %s", method)
+			os.Exit(1)
+		}
+		a, err := auth.New(method, username, password, "", "")
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		if err := verifyCredentials(a); err != nil {
+			logger.Errorf("Login Error: %v", err)
+			os.Exit(1)
+		}
+		store.Set(NexusHostURL, credentials.Credential{Username: username, Password: password})
+		if err := store.Save(); err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&loginResult{Host: NexusHostURL, Username: username, File: store.Path})
+	},
+}
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Removes the credentials saved by login for a host url.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if NexusHostURL == "" {
+			logger.Errorf(`Error: required flag(s) "hostURL" not set`)
+			os.Exit(1)
+		}
+		store, err := openCredentials()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		result := &logoutResult{Host: NexusHostURL, Removed: store.Delete(NexusHostURL)}
+		if result.Removed {
+			if err := store.Save(); err != nil {
+				logger.Errorf("Error: %v", err)
+				os.Exit(1)
+			}
+		}
+		printResult(result)
+	},
+}
+
+// openCredentials opens the credentials store with the key of the environment
+func openCredentials() (*credentials.Store, error) {
+	path, err := credentials.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	key, err := credentials.KeyFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.Open(path, key)
+}
+
+// applyStoredCredentials fills the username and password that are still empty from the login store.
+// The store is skipped silently when it does not exist or no key is set.
+func applyStoredCredentials() {
+	if NexusHostURL == "" || (NexusUsername != "" && NexusPassword != "") {
+		return
+	}
+	path, err := credentials.DefaultPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	store, err := openCredentials()
+	if err != nil {
+		logger.Debugf("Not using the credentials of %s: %v", path, err)
+		return
+	}
+	c, found := store.Get(NexusHostURL)
+	if !found {
+		return
+	}
+	if NexusUsername == "" {
+		NexusUsername = c.Username
+	}
+	if NexusPassword == "" {
+		NexusPassword = c.Password
+	}
+	logger.Debugf("Using the stored credentials of %s", NexusHostURL)
+}
+
+// promptCredentials returns the username and password of the flags, reading the missing ones
+// from the terminal. With --password-stdin the password is read from stdin instead.
+func promptCredentials() (string, string, error) {
+	username, password := NexusUsername, NexusPassword
+	if passwordStdin {
+		if username == "" {
+			return "", "", fmt.Errorf("--password-stdin requires --username")
+		}
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", "", err
+		}
+		return username, strings.TrimRight(string(b), "\r\n"), nil
+	}
+	if (username == "" || password == "") && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", "", fmt.Errorf("cannot prompt for credentials without a terminal, use --username and --password-stdin")
+	}
+	if username == "" {
+		fmt.Fprint(os.Stderr, "Username: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+		username = strings.TrimSpace(line)
+	}
+	if password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", "", err
+		}
+		password = string(b)
+	}
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("the username and password must not be empty")
+	}
+	return username, password, nil
+}
+
+// verifyCredentials checks the credentials against the Nexus version of --nexus-version or the profile,
+// detected from the server when neither is set
+func verifyCredentials(a auth.Authenticator) error {
+	client, err := nexus.New(requireNexusVersion(), nexus.Server{HostURL: NexusHostURL, Auth: a, HTTPClient: serverHTTPClient})
+	if err != nil {
+		return err
+	}
+	return client.CheckCredentials()
+}
+
+// loginResult is the result of the login command
+type loginResult struct {
+	Host     string `json:"host" yaml:"host"`
+	Username string `json:"username" yaml:"username"`
+	File     string `json:"file" yaml:"file"`
+}
+
+func (r *loginResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Login succeeded for %s on %s, credentials saved to %s\n", r.Username, r.Host, r.File)
+}
+
+// logoutResult is the result of the logout command
+type logoutResult struct {
+	Host    string `json:"host" yaml:"host"`
+	Removed bool   `json:"removed" yaml:"removed"`
+}
+
+func (r *logoutResult) printText(w io.Writer) {
+	if r.Removed {
+		fmt.Fprintf(w, "Removed the credentials of %s\n", r.Host)
+	} else {
+		fmt.Fprintf(w, "No credentials saved for %s\n", r.Host)
+	}
+}
+
+var passwordStdin bool
+
+func init() {
+	RootCmd.AddCommand(loginCmd)
+	RootCmd.AddCommand(logoutCmd)
+	loginCmd.PersistentFlags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from stdin.")
+}
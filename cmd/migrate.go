@@ -0,0 +1,128 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bzon/nexus-cli/migrate"
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copies a hosted repository into a repository of another Nexus server.",
+	Long: `Copies a hosted repository into a repository of another Nexus server.
+
+The source is the server of the host flags or the profile, typically a Nexus 2 server,
+the target is the server of --target-profile, typically a Nexus 3 server. Both may be
+Nexus 2 or Nexus 3. Every file of the source repository is uploaded unless the target
+already has it with the same sha1. Maven metadata and checksum files are generated by
+the target and not copied. The migrated files are recorded in --state-file,
+so an interrupted migration can be run again to resume it. The target is listed at the
+end and compared with the source. For example:
+nexus-cli migrate --profile nexus2 -r releases --target-profile nexus3 --target-repo maven-releases --parallel 8`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		requireRepository(&migrateRepository)
+		targetServer, err := profileServer(migrateTargetProfile)
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		if migrateTargetRepository == "" {
+			migrateTargetRepository = targetServer.Repository
+		}
+		if migrateTargetRepository == "" {
+			logger.Errorf(`Error: required flag(s) "target-repo" not set`)
+			os.Exit(1)
+		}
+		target, err := targetServer.nexusClient()
+		if err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+		if migrateStateFile == "" {
+			migrateStateFile = "migrate-" + migrateRepository + "-" + migrateTargetRepository + ".json"
+		}
+		m := migrate.Migration{
+			Source:           requireClient(),
+			SourceRepository: migrateRepository,
+			Target:           target,
+			TargetRepository: migrateTargetRepository,
+			Parallel:         migrateParallel,
+			StatePath:        migrateStateFile,
+			Log:              logger,
+		}
+		report, err := m.Run()
+		if report != nil {
+			printResult(&migrateResult{Report: *report, StateFile: migrateStateFile})
+		}
+		if err != nil {
+			logger.Errorf("Migrate Error: %v", err)
+			os.Exit(1)
+		}
+		if !report.OK() {
+			os.Exit(1)
+		}
+	},
+}
+
+// migrateResult is the result of the migrate command
+type migrateResult struct {
+	migrate.Report `yaml:",inline"`
+	StateFile      string `json:"stateFile" yaml:"stateFile"`
+}
+
+func (r *migrateResult) printText(w io.Writer) {
+	for _, f := range r.Failed {
+		fmt.Fprintf(w, "Failed %s: %s\n", f.Path, f.Error)
+	}
+	for _, path := range r.Missing {
+		fmt.Fprintln(w, "Missing from the target:", path)
+	}
+	for _, path := range r.Mismatched {
+		fmt.Fprintln(w, "Different sha1 in the target:", path)
+	}
+	for _, f := range r.Unverified {
+		fmt.Fprintf(w, "Unverified %s: %s\n", f.Path, f.Error)
+	}
+	fmt.Fprintf(w, "Source %s: %d files, %s\n", r.Source, r.Files, formatSize(r.Bytes))
+	fmt.Fprintf(w, "Target %s: %d migrated, %d already present, %d failed\n", r.Target, len(r.Migrated), len(r.Skipped), len(r.Failed))
+	fmt.Fprintf(w, "Reconciliation: %d verified, %d missing, %d with a different sha1, %d unverified\n",
+		r.Verified, len(r.Missing), len(r.Mismatched), len(r.Unverified))
+}
+
+var migrateRepository, migrateTargetProfile, migrateTargetRepository, migrateStateFile string
+var migrateParallel int
+
+func init() {
+	RootCmd.AddCommand(migrateCmd)
+	migrateCmd.PersistentFlags().StringVarP(&migrateRepository, "repo", "r", "", "The source repository id. Example: 'releases'")
+	migrateCmd.PersistentFlags().StringVar(&migrateTargetProfile, "target-profile", "", "The profile of the target server.")
+	migrateCmd.PersistentFlags().StringVar(&migrateTargetRepository, "target-repo", "", "The target repository. Defaults to the repository of the target profile.")
+	migrateCmd.PersistentFlags().IntVar(&migrateParallel, "parallel", 4, "The number of files copied at a time.")
+	migrateCmd.PersistentFlags().StringVar(&migrateStateFile, "state-file", "", "The file recording the migrated files. Defaults to migrate-<repo>-<target-repo>.json in the current directory.")
+	migrateCmd.MarkPersistentFlagRequired("target-profile")
+}
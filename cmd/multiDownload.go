@@ -21,12 +21,20 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
 
-	"github.com/bzon/nexus-cli/nexus2"
+	"github.com/bzon/nexus-cli/manifest"
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
 
 	"github.com/spf13/cobra"
 )
@@ -40,38 +48,351 @@ var multiDownloadCmd = &cobra.Command{
 Or give it a configuration '.txt' file with correct formatting. For example:
 nexus-cli multi-download -H http://localhost:8087 -f artifacts.txt -d /tmp/
 
-And the example content of 'artifacts.txt' is written in G:A:V:P format:
+And the example content of 'artifacts.txt' is written in G:A:V:P[:C] format.
+Blank lines and lines starting with '#' are ignored:
 ----------------------------
+# release artifacts
 com.foo.group:bar:LATEST:jar
-com.baz.group:foo:1.0.0:jar
-----------------------------`,
+com.baz.group:foo:1.0.0:jar:sources
+----------------------------
+
+Structured '.yaml', '.yml' or '.json' manifests are also supported. For example:
+----------------------------
+server:
+  host: http://localhost:8087
+defaults:
+  repository: releases
+  packaging: jar
+artifacts:
+  - group: com.foo.group
+    artifact: bar
+  - group: com.baz.group
+    artifact: foo
+    version: 1.0.0
+    classifier: sources
+    destination: /tmp/sources
+    rename: foo-sources.jar
+    sha1: 0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33
+----------------------------
+The 'server' section is used only when no host is given by --hostURL, the
+environment, the profile or the Maven settings. Its username and password are
+then used unless credentials are given another way, and never with another host.
+
+A Maven 'pom.xml' (dependencies and dependencyManagement) or a Gradle
+'gradle.lockfile' can be given too. Use --scope and --configuration to select
+the dependencies to download. For example:
+nexus-cli multi-download -H http://localhost:8087 -f pom.xml --scope compile,runtime
+nexus-cli multi-download -H http://localhost:8087 -f gradle.lockfile --configuration runtimeClasspath
+
+Artifacts are downloaded by --parallel workers. A summary of the succeeded and
+failed artifacts is printed at the end and the exit code is non-zero when any
+artifact failed. Use --fail-fast to stop after the first failure.
+
+After a successful download the resolved version, repository path and sha1 of
+every artifact are written to a lock file, 'artifacts.lock' for 'artifacts.txt'.
+With --locked the pinned versions are downloaded instead and any checksum drift
+fails the download. Run 'nexus-cli lock update' to refresh the pins.
+
+With --layout maven the files are placed in a Maven local repository, with their
+pom, .sha1 files and Maven bookkeeping, so that Maven builds find them offline:
+nexus-cli multi-download -f artifacts.yaml --layout maven`,
 	Run: func(cmd *cobra.Command, args []string) {
-		b, err := ioutil.ReadFile(configFile)
+		m, err := loadManifest(configFile)
 		if err != nil {
-			fmt.Printf("ERROR: %v\n", err)
+			logger.Errorf("ERROR: %v", err)
 			os.Exit(1)
 		}
-		artifacts := strings.Split(string(b), "\n")
-		var aRequest nexus2.ArtifactRequest
-		aRequest.HostURL = NexusHostURL
-		aRequest.Username = NexusUsername
-		aRequest.Password = NexusPassword
-		aRequest.DestinationDir = destinationDir
-		// for txt files
-		if strings.HasSuffix(configFile, ".txt") {
-			for i, a := range artifacts {
-				fmt.Printf("============== [%d] - Found %s in %s ==============\n", i, a, configFile)
-				aRequest.GroupID = strings.Split(a, ":")[0]
-				aRequest.Artifact = strings.Split(a, ":")[1]
-				aRequest.Version = strings.Split(a, ":")[2]
-				aRequest.Packaging = strings.Split(a, ":")[3]
-				nexus2.DownloadArtifact(aRequest)
+		applyManifestServer(m.Server)
+		requireServer()
+		checkLayout(cmd, &destinationDir)
+		base := nexus.Request{Repository: profile.Repository, Dir: destinationDir}
+
+		if lockFile == "" {
+			lockFile = manifest.LockPath(configFile)
+		}
+		var lock *manifest.Lock
+		if locked {
+			if lock, err = manifest.ReadLock(lockFile); err != nil {
+				logger.Errorf("ERROR: %v", err)
+				os.Exit(1)
 			}
 		}
+
+		results := downloadAll(requireClient(), m.Artifacts, base, lock)
+		summary := newMultiDownloadResult(results)
+		if summary.Failed == 0 && !locked {
+			if err := writeLock(results, lockFile); err != nil {
+				logger.Errorf("ERROR: %v", err)
+				os.Exit(1)
+			}
+			summary.LockFile = lockFile
+		}
+		printResult(summary)
+		if summary.Failed > 0 {
+			os.Exit(1)
+		}
 	},
 }
 
+// downloadResult is the outcome of downloading a single manifest entry
+type downloadResult struct {
+	entry  manifest.Entry
+	locked manifest.LockedArtifact
+	result artifactResult
+	// missing is set when the entry failed because it is not in the offline cache
+	missing bool
+}
+
+// downloadAll downloads the entries with a pool of parallelDownloads workers.
+// With failFast set, no new download is started once one has failed.
+// When lock is not nil, every entry is downloaded at its locked version.
+func downloadAll(client nexus.Client, entries []manifest.Entry, base nexus.Request, lock *manifest.Lock) []downloadResult {
+	results := make([]downloadResult, len(entries))
+	workers := parallelDownloads
+	if workers < 1 {
+		workers = 1
+	}
+	var failed int32
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entry := entries[i]
+				results[i].entry = entry
+				if failFast && atomic.LoadInt32(&failed) > 0 {
+					results[i].result = artifactResult{
+						Coordinates: entry.String(),
+						Status:      statusSkipped,
+						Error:       "not attempted after an earlier failure",
+					}
+					continue
+				}
+				logger.Infof("============== [%d] - Found %s in %s:%d ==============", i, entry, configFile, entry.Line)
+				start := time.Now()
+				var filePath string
+				var err error
+				results[i].locked, filePath, err = downloadEntry(client, entry, base, lock)
+				results[i].result = newArtifactResult(entry.String(), results[i].locked, filePath, start, err)
+				results[i].missing = errors.Is(err, nexus.ErrNotCached)
+				if err != nil {
+					atomic.AddInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// downloadEntry resolves and downloads a single entry and returns its resolution
+func downloadEntry(client nexus.Client, entry manifest.Entry, base nexus.Request, lock *manifest.Lock) (manifest.LockedArtifact, string, error) {
+	r := entry.Request(base)
+	if lock != nil {
+		pinned, found := lock.Find(entry)
+		if !found {
+			return manifest.LockedArtifact{}, "", fmt.Errorf("not found in the lock file, run 'nexus-cli lock update'")
+		}
+		var err error
+		if r, err = pinned.Pin(r); err != nil {
+			return manifest.LockedArtifact{}, "", err
+		}
+	}
+	res, err := nexus.ResolveArtifact(client, r)
+	if err != nil {
+		return manifest.LockedArtifact{}, "", err
+	}
+	var filePath string
+	if layout == "maven" {
+		filePath, err = downloadToRepository(client, r, res)
+	} else {
+		filePath, err = nexus.DownloadResolved(client, r, res)
+	}
+	return manifest.NewLockedArtifact(entry, r, res), filePath, err
+}
+
+// downloadToRepository downloads a resolved artifact and its pom into the Maven repository
+// layout of the request directory. A pom that cannot be downloaded is only a warning.
+func downloadToRepository(client nexus.Client, r nexus.Request, res *nexus.Resolution) (string, error) {
+	repository := maven.LocalRepository{Dir: r.Dir}
+	a := mavenArtifact(r, res)
+	filePath, err := downloadInstall(client, repository, a, r, res)
+	if err != nil || a.Extension == "pom" {
+		return filePath, err
+	}
+
+	pomRequest := r
+	pomRequest.Version = res.Version
+	pomRequest.Classifier, pomRequest.Extension, pomRequest.Sha1 = "", "pom", ""
+	pom := a
+	pom.Classifier, pom.Extension = "", "pom"
+	if _, err := os.Stat(filepath.Join(repository.VersionDir(pom), repository.FileName(pom))); err == nil {
+		return filePath, nil
+	}
+	pomResolution, err := nexus.ResolveArtifact(client, pomRequest)
+	if err == nil {
+		_, err = downloadInstall(client, repository, mavenArtifact(pomRequest, pomResolution), pomRequest, pomResolution)
+	}
+	if err != nil {
+		logger.Warnf("Cannot download the pom of %s: %v", coordinates(r), err)
+	}
+	return filePath, nil
+}
+
+// downloadInstall downloads a resolved file to its place in a Maven repository and writes its bookkeeping
+func downloadInstall(client nexus.Client, repository maven.LocalRepository, a maven.Artifact, r nexus.Request, res *nexus.Resolution) (string, error) {
+	r.Dir = repository.VersionDir(a)
+	r.Filename = repository.FileName(a)
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return "", err
+	}
+	filePath, err := nexus.DownloadResolved(client, r, res)
+	if err != nil {
+		return "", err
+	}
+	return filePath, repository.Install(a, res.Sha1)
+}
+
+// mavenArtifact returns the Maven repository coordinates of a resolved request
+func mavenArtifact(r nexus.Request, res *nexus.Resolution) maven.Artifact {
+	return maven.Artifact{
+		GroupID:     res.Group,
+		ArtifactID:  res.Artifact,
+		Version:     res.Version,
+		BaseVersion: res.BaseVersion,
+		Classifier:  r.Classifier,
+		Extension:   res.Extension,
+	}
+}
+
+// checkLayout validates --layout and makes ~/.m2/repository the default destination of the maven layout
+func checkLayout(cmd *cobra.Command, destination *string) {
+	switch layout {
+	case "flat":
+	case "maven":
+		if !cmd.Flags().Changed("destination") {
+			path, err := maven.DefaultLocalRepositoryPath()
+			if err != nil {
+				logger.Errorf("Error: %v", err)
+				os.Exit(1)
+			}
+			*destination = path
+		}
+	default:
+		logger.Errorf("Error: --layout must be flat or maven, got %q", layout)
+		os.Exit(1)
+	}
+}
+
+// writeLock writes the resolutions of the downloaded entries to the lock file
+func writeLock(results []downloadResult, path string) error {
+	var lock manifest.Lock
+	for _, r := range results {
+		lock.Artifacts = append(lock.Artifacts, r.locked)
+	}
+	return lock.Write(path)
+}
+
+// multiDownloadResult is the result of the multi-download command
+type multiDownloadResult struct {
+	Artifacts []artifactResult `json:"artifacts" yaml:"artifacts"`
+	Succeeded int              `json:"succeeded" yaml:"succeeded"`
+	Failed    int              `json:"failed" yaml:"failed"`
+	LockFile  string           `json:"lockFile,omitempty" yaml:"lockFile,omitempty"`
+	// Missing lists the artifacts that are not in the cache of --offline
+	Missing []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+}
+
+func newMultiDownloadResult(results []downloadResult) *multiDownloadResult {
+	summary := new(multiDownloadResult)
+	for _, r := range results {
+		summary.Artifacts = append(summary.Artifacts, r.result)
+		if r.result.Status == statusOK {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		if r.missing {
+			summary.Missing = append(summary.Missing, r.result.Coordinates)
+		}
+	}
+	return summary
+}
+
+// printText prints a table of every download
+func (m *multiDownloadResult) printText(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tARTIFACT\tVERSION\tFILE / REASON")
+	for _, r := range m.Artifacts {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.ToUpper(r.Status), r.Coordinates, r.Version, r.Error)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", strings.ToUpper(r.Status), r.Coordinates, r.Version, r.File)
+		}
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%d succeeded, %d failed\n", m.Succeeded, m.Failed)
+	if len(m.Missing) > 0 {
+		fmt.Fprintln(out, "Missing from the offline cache, download them once online:")
+		for _, coordinates := range m.Missing {
+			fmt.Fprintln(out, "  "+coordinates)
+		}
+	}
+	if m.LockFile != "" {
+		fmt.Fprintln(out, "Wrote lock file", m.LockFile)
+	}
+}
+
+// loadManifest loads an artifacts file and keeps the entries selected by --scope and --configuration
+func loadManifest(path string) (*manifest.Manifest, error) {
+	m, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	m.Artifacts = manifest.Filter(m.Artifacts, manifestScopes, manifestConfigurations)
+	return m, nil
+}
+
+// applyManifestServer uses the server of a manifest as one unit, only when no host was given by a flag,
+// an environment variable, the profile or the Maven settings. Its credentials go to its own host only:
+// they are ignored with any other host, and when credentials were given another way.
+func applyManifestServer(s manifest.Server) {
+	if s.Host == "" && (s.Username != "" || s.Password != "") {
+		logger.Warnf("Ignoring the credentials of the manifest server without a host")
+		return
+	}
+	if s.Host == "" {
+		return
+	}
+	if NexusHostURL != "" {
+		if s.Username != "" || s.Password != "" {
+			logger.Warnf("Ignoring the manifest server %s and its credentials, the host is %s", s.Host, NexusHostURL)
+		} else {
+			logger.Debugf("Ignoring the manifest server %s, the host is %s", s.Host, NexusHostURL)
+		}
+		return
+	}
+	NexusHostURL = s.Host
+	if NexusUsername == "" && NexusPassword == "" {
+		NexusUsername, NexusPassword = s.Username, s.Password
+	}
+}
+
 var configFile, destinationDir string
+var parallelDownloads int
+var failFast bool
+var lockFile string
+var locked bool
+
+// layout is the --layout of the downloaded files: flat or maven
+var layout string
+var manifestScopes, manifestConfigurations []string
 
 func init() {
 	RootCmd.AddCommand(multiDownloadCmd)
@@ -79,4 +400,11 @@ func init() {
 	multiDownloadCmd.MarkPersistentFlagRequired("file")
 	cwd, _ := os.Getwd()
 	multiDownloadCmd.PersistentFlags().StringVarP(&destinationDir, "destination", "d", cwd, "The directory where to place the file.")
+	multiDownloadCmd.PersistentFlags().StringVar(&layout, "layout", "flat", "Place the files flat in the destination, or in the Maven repository layout with 'maven'. The maven layout defaults the destination to ~/.m2/repository.")
+	multiDownloadCmd.PersistentFlags().IntVar(&parallelDownloads, "parallel", 1, "The number of artifacts downloaded in parallel.")
+	multiDownloadCmd.PersistentFlags().BoolVar(&failFast, "fail-fast", false, "Stop starting new downloads after the first failure instead of continuing.")
+	multiDownloadCmd.PersistentFlags().StringVar(&lockFile, "lock-file", "", "The lock file. Defaults to the artifacts file with a '.lock' extension.")
+	multiDownloadCmd.PersistentFlags().BoolVar(&locked, "locked", false, "Download exactly the versions pinned in the lock file and fail on any checksum drift.")
+	multiDownloadCmd.PersistentFlags().StringSliceVar(&manifestScopes, "scope", nil, "Only download the pom.xml dependencies in these Maven scopes. Example: compile,runtime")
+	multiDownloadCmd.PersistentFlags().StringSliceVar(&manifestConfigurations, "configuration", nil, "Only download the gradle.lockfile dependencies of these Gradle configurations. Example: runtimeClasspath")
 }
@@ -0,0 +1,151 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bzon/nexus-cli/manifest"
+	"github.com/bzon/nexus-cli/nexus"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// outputFormat is the --output format of command results: text, json or yaml
+var outputFormat string
+
+// textResult is implemented by results that render themselves for --output text
+type textResult interface {
+	printText(w io.Writer)
+}
+
+// checkOutputFormat exits when --output is not a known format
+func checkOutputFormat() {
+	switch outputFormat {
+	case "text", "json", "yaml":
+	default:
+		logger.Errorf("Error: --output must be one of text, json or yaml, got %q", outputFormat)
+		os.Exit(1)
+	}
+}
+
+// printResult writes the single result of a command to stdout in the --output format.
+// Human readable logs go to stderr so that stdout can be parsed by scripts.
+func printResult(result interface{}) {
+	var err error
+	switch outputFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(result)
+	case "yaml":
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		err = enc.Encode(result)
+		if err == nil {
+			err = enc.Close()
+		}
+	default:
+		if t, ok := result.(textResult); ok {
+			t.printText(os.Stdout)
+		} else {
+			fmt.Println(result)
+		}
+	}
+	if err != nil {
+		logger.Errorf("ERROR: %v", err)
+		os.Exit(1)
+	}
+}
+
+// artifactResult is the outcome of downloading or resolving a single artifact
+type artifactResult struct {
+	// Coordinates are the requested G:A:V:P[:C] coordinates
+	Coordinates string `json:"coordinates" yaml:"coordinates"`
+	Repository  string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	// Version is the version resolved by Nexus
+	Version    string `json:"version,omitempty" yaml:"version,omitempty"`
+	File       string `json:"file,omitempty" yaml:"file,omitempty"`
+	Size       int64  `json:"size,omitempty" yaml:"size,omitempty"`
+	Sha1       string `json:"sha1,omitempty" yaml:"sha1,omitempty"`
+	DurationMs int64  `json:"durationMs" yaml:"durationMs"`
+	Status     string `json:"status" yaml:"status"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Extracted is the directory the archive was unpacked to with --extract
+	Extracted        string `json:"extracted,omitempty" yaml:"extracted,omitempty"`
+	ExtractedEntries int    `json:"extractedEntries,omitempty" yaml:"extractedEntries,omitempty"`
+	ArchiveRemoved   bool   `json:"archiveRemoved,omitempty" yaml:"archiveRemoved,omitempty"`
+}
+
+const (
+	statusOK      = "ok"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// newArtifactResult builds the result of a download that started at start
+func newArtifactResult(coordinates string, locked manifest.LockedArtifact, filePath string, start time.Time, err error) artifactResult {
+	r := artifactResult{
+		Coordinates: coordinates,
+		Repository:  locked.Repository,
+		Version:     locked.Version,
+		File:        filePath,
+		Sha1:        locked.Sha1,
+		DurationMs:  time.Since(start).Nanoseconds() / int64(time.Millisecond),
+		Status:      statusOK,
+	}
+	if err != nil {
+		r.Status = statusFailed
+		r.Error = err.Error()
+		r.File = ""
+	} else if info, statErr := os.Stat(filePath); statErr == nil {
+		r.Size = info.Size()
+	}
+	return r
+}
+
+func (r artifactResult) printText(w io.Writer) {
+	if r.Error != "" {
+		fmt.Fprintf(w, "%s: %s\n", r.Coordinates, r.Error)
+		return
+	}
+	fmt.Fprintf(w, "%s resolved to %s: %s (%d bytes, sha1 %s)\n", r.Coordinates, r.Version, r.File, r.Size, r.Sha1)
+	if r.Extracted != "" {
+		fmt.Fprintf(w, "Extracted %d entries to %s\n", r.ExtractedEntries, r.Extracted)
+	}
+	if r.ArchiveRemoved {
+		fmt.Fprintf(w, "Removed %s\n", r.File)
+	}
+}
+
+// coordinates returns the G:A:V:P[:C] coordinates of a request, the extension standing for the packaging
+func coordinates(r nexus.Request) string {
+	return manifest.Entry{
+		Group:      r.Group,
+		Artifact:   r.Artifact,
+		Version:    r.Version,
+		Packaging:  r.Extension,
+		Classifier: r.Classifier,
+	}.String()
+}
@@ -0,0 +1,408 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/logging"
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/spf13/viper"
+)
+
+// Profile holds the settings of a named Nexus server in the config file.
+// For example:
+//
+//	current-profile: prod
+//	profiles:
+//	  prod:
+//	    host: https://nexus.example.com
+//	    version: 3
+//	    username: deployer
+//	    password-env: NEXUS_PROD_PASSWORD
+//	    server-id: nexus-releases
+//	    auth: basic
+//	    repository: releases
+//	    tls:
+//	      ca-file: /etc/ssl/certs/nexus-ca.pem
+type Profile struct {
+	Host    string `mapstructure:"host" json:"host" yaml:"host"`
+	Version int    `mapstructure:"version" json:"version,omitempty" yaml:"version,omitempty"`
+
+	Username string `mapstructure:"username" json:"username,omitempty" yaml:"username,omitempty"`
+	// Password is read from the PasswordEnv environment variable, the PasswordFile file or the plain Password
+	Password     string `mapstructure:"password" json:"-" yaml:"-"`
+	PasswordEnv  string `mapstructure:"password-env" json:"passwordEnv,omitempty" yaml:"passwordEnv,omitempty"`
+	PasswordFile string `mapstructure:"password-file" json:"passwordFile,omitempty" yaml:"passwordFile,omitempty"`
+
+	// Auth is the authentication method, see --auth. Token and TokenEnv hold the bearer token
+	// and NetrcFile the netrc file of the profile.
+	Auth      string `mapstructure:"auth" json:"auth,omitempty" yaml:"auth,omitempty"`
+	Token     string `mapstructure:"token" json:"-" yaml:"-"`
+	TokenEnv  string `mapstructure:"token-env" json:"tokenEnv,omitempty" yaml:"tokenEnv,omitempty"`
+	NetrcFile string `mapstructure:"netrc-file" json:"netrcFile,omitempty" yaml:"netrcFile,omitempty"`
+
+	// ServerID is the id of a settings.xml <server> entry that supplies the credentials
+	ServerID string `mapstructure:"server-id" json:"serverId,omitempty" yaml:"serverId,omitempty"`
+
+	// Repository is used by the commands when no repository flag is given
+	Repository string `mapstructure:"repository" json:"repository,omitempty" yaml:"repository,omitempty"`
+
+	TLS struct {
+		InsecureSkipVerify bool   `mapstructure:"insecure-skip-verify" json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+		CAFile             string `mapstructure:"ca-file" json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	} `mapstructure:"tls" json:"tls" yaml:"tls"`
+}
+
+// profileName is the --profile flag
+var profileName string
+
+// activeProfile is the name of the profile in use, empty when no profile is used
+var activeProfile string
+
+// profile holds the settings of the profile in use
+var profile Profile
+
+// readProfiles returns the profiles of the config file by name.
+// Profile names are case insensitive.
+func readProfiles() (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+	if err := viper.UnmarshalKey("profiles", &profiles); err != nil {
+		return nil, fmt.Errorf("%s: invalid profiles: %v", viper.ConfigFileUsed(), err)
+	}
+	return profiles, nil
+}
+
+// loadProfile selects the profile named by --profile, $NEXUS_PROFILE or current-profile, in that order
+func loadProfile() error {
+	name, explicit := profileName, true
+	if name == "" {
+		name = os.Getenv("NEXUS_PROFILE")
+	}
+	if name == "" {
+		name, explicit = viper.GetString("current-profile"), false
+	}
+	if name == "" {
+		return nil
+	}
+	profiles, err := readProfiles()
+	if err != nil {
+		return err
+	}
+	p, found := profiles[strings.ToLower(name)]
+	if !found {
+		if !explicit {
+			logger.Warnf("current-profile %q is not defined in %s", name, viper.ConfigFileUsed())
+			return nil
+		}
+		return fmt.Errorf("profile %q is not defined in %s", name, viper.ConfigFileUsed())
+	}
+	if p.Version != 0 && p.Version != 2 && p.Version != 3 {
+		return fmt.Errorf("profile %q: version must be 2 or 3, got %d", name, p.Version)
+	}
+	profile, activeProfile = p, strings.ToLower(name)
+	return nil
+}
+
+// applyProfile fills the server settings that were not given by flags or environment variables,
+// so the precedence is flag > env > profile
+func applyProfile() error {
+	if NexusHostURL == "" {
+		NexusHostURL = profile.Host
+	}
+	if NexusUsername == "" {
+		NexusUsername = profile.Username
+	}
+	if NexusPassword == "" {
+		password, err := profile.password()
+		if err != nil {
+			return err
+		}
+		NexusPassword = password
+	}
+	if NexusToken == "" {
+		NexusToken = profile.Token
+		if profile.TokenEnv != "" {
+			NexusToken = os.Getenv(profile.TokenEnv)
+		}
+	}
+	if netrcFile == "" {
+		netrcFile = profile.NetrcFile
+	}
+	return nil
+}
+
+// serverID is the --server-id flag
+var serverID string
+
+// mavenSettings is the --maven-settings flag
+var mavenSettings string
+
+// mavenSettingsSecurity is the --maven-settings-security flag
+var mavenSettingsSecurity string
+
+// applyMavenSettings fills the credentials that are still empty from the settings.xml server
+// selected by --server-id or the profile, and the host from the mirror with the same id
+func applyMavenSettings() error {
+	id := serverID
+	if id == "" {
+		id = profile.ServerID
+	}
+	if id == "" {
+		return nil
+	}
+	path := mavenSettings
+	if path == "" {
+		var err error
+		if path, err = maven.DefaultSettingsPath(); err != nil {
+			return err
+		}
+	}
+	settings, err := maven.ReadSettings(path)
+	if err != nil {
+		return err
+	}
+	settings.SecurityPath = mavenSettingsSecurity
+	if NexusUsername == "" || NexusPassword == "" {
+		username, password, err := settings.Credentials(id)
+		if err != nil {
+			return err
+		}
+		if NexusUsername == "" {
+			NexusUsername = username
+		}
+		if NexusPassword == "" {
+			NexusPassword = password
+		}
+	}
+	if mirror, found := settings.Mirror(id); found && NexusHostURL == "" {
+		NexusHostURL = maven.HostURL(mirror.URL)
+	}
+	logger.Debugf("Using server %s of %s", id, path)
+	return nil
+}
+
+// password resolves the credentials reference of the profile
+func (p Profile) password() (string, error) {
+	switch {
+	case p.PasswordEnv != "":
+		return os.Getenv(p.PasswordEnv), nil
+	case p.PasswordFile != "":
+		b, err := ioutil.ReadFile(p.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	default:
+		return p.Password, nil
+	}
+}
+
+// tlsConfig returns the TLS settings of the profile, nil when the defaults apply
+func (p Profile) tlsConfig() (*tls.Config, error) {
+	if !p.TLS.InsecureSkipVerify && p.TLS.CAFile == "" {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: p.TLS.InsecureSkipVerify}
+	if p.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(p.TLS.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificate found", p.TLS.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// serverHTTPClient executes the requests to the server in use. It is set by initHTTP.
+var serverHTTPClient *http.Client
+
+// initHTTP builds the HTTP client of the server in use from the profile TLS settings, --http-debug and --offline
+func initHTTP() error {
+	client, err := httpClient(profile)
+	if err != nil {
+		return err
+	}
+	serverHTTPClient = client
+	return nil
+}
+
+// httpClient returns an HTTP client with the TLS settings of a profile, --http-debug and --offline
+func httpClient(p Profile) (*http.Client, error) {
+	var transport http.RoundTripper = http.DefaultTransport
+	tlsConfig, err := p.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = tlsConfig
+		transport = t
+	}
+	if httpDebug {
+		transport = &logging.Transport{Base: transport, Logger: logger}
+	}
+	if offline {
+		transport = offlineTransport{}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// server is the Nexus server of a profile other than the one in use, for the commands
+// that talk to two servers
+type server struct {
+	Profile
+	name, password string
+	auth           auth.Authenticator
+	client         *http.Client
+}
+
+// profileServer returns the server of a profile with its credentials, authentication and HTTP client
+func profileServer(name string) (server, error) {
+	profiles, err := readProfiles()
+	if err != nil {
+		return server{}, err
+	}
+	p, found := profiles[strings.ToLower(name)]
+	if !found {
+		return server{}, fmt.Errorf("profile %q is not defined in %s", name, viper.ConfigFileUsed())
+	}
+	if p.Host == "" {
+		return server{}, fmt.Errorf("profile %q has no host", name)
+	}
+	s := server{Profile: p, name: strings.ToLower(name)}
+	if s.password, err = p.password(); err != nil {
+		return server{}, err
+	}
+	token := p.Token
+	if p.TokenEnv != "" {
+		token = os.Getenv(p.TokenEnv)
+	}
+	method := p.Auth
+	if method == "" {
+		method = defaultAuthMethod(p.Username, s.password, token)
+	}
+	if s.auth, err = auth.New(method, p.Username, s.password, token, p.NetrcFile); err != nil {
+		return server{}, fmt.Errorf("profile %q: %v", name, err)
+	}
+	if s.client, err = httpClient(p); err != nil {
+		return server{}, err
+	}
+	return s, nil
+}
+
+// nexusClient returns the repository client of the server, detecting its version when the profile has none
+func (s server) nexusClient() (nexus.Client, error) {
+	ns := nexus.Server{HostURL: s.Host, Username: s.Username, Password: s.password, Auth: s.auth, HTTPClient: s.client}
+	version := s.Version
+	if version == 0 {
+		var err error
+		if version, err = nexus.Detect(ns); err != nil {
+			return nil, err
+		}
+	}
+	return nexus.New(version, ns)
+}
+
+// offlineTransport refuses every request of --offline
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("refusing to send %s %s in offline mode", req.Method, req.URL.Redacted())
+}
+
+// nexusVersion is the --nexus-version flag
+var nexusVersion int
+
+// requireNexusVersion returns the Nexus major version of --nexus-version or the profile, detects it
+// from the server when neither is set, and exits when it is unknown. In offline mode, where the
+// resolutions and files come from the cache whatever the version, it defaults to 3 instead.
+func requireNexusVersion() int {
+	if nexusVersion == 0 {
+		nexusVersion = profile.Version
+	}
+	if nexusVersion == 0 && offline {
+		nexusVersion = 3
+	}
+	if nexusVersion == 0 {
+		version, err := nexus.Detect(nexus.Server{HostURL: NexusHostURL, HTTPClient: serverHTTPClient})
+		if err != nil {
+			logger.Errorf("Error: %v, set --nexus-version or the version of the profile", err)
+			os.Exit(1)
+		}
+		logger.Debugf("Detected Nexus %d on %s", version, NexusHostURL)
+		nexusVersion = version
+	}
+	if nexusVersion != 2 && nexusVersion != 3 {
+		logger.Errorf("Error: set --nexus-version or the version of the profile to 2 or 3")
+		os.Exit(1)
+	}
+	return nexusVersion
+}
+
+// requireClient returns the repository client of the server
+func requireClient() nexus.Client {
+	client, err := nexus.New(requireNexusVersion(), nexus.Server{
+		HostURL: NexusHostURL, Username: NexusUsername, Password: NexusPassword, Auth: serverAuth, HTTPClient: serverHTTPClient,
+	})
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+// requireRepository fills an empty repository from the profile and exits when there is none
+func requireRepository(repository *string) {
+	if *repository == "" {
+		*repository = profile.Repository
+	}
+	if *repository == "" {
+		logger.Errorf(`Error: required flag(s) "repo" not set`)
+		os.Exit(1)
+	}
+}
+
+// profileNames returns the sorted names of the profiles
+func profileNames(profiles map[string]Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
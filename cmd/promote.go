@@ -0,0 +1,104 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Copies a component and all its files from one repository to another.",
+	Long: `Copies a component and all its files from one repository to another.
+
+Every file of the component (jar, pom, classifiers and .asc signatures) is downloaded
+from --from, verified against the source sha1, uploaded to --to and verified
+again. Nexus 2 copies the files of the version directory through the content
+paths, Nexus 3 uploads the assets with the components API. With --delete-source
+the source component is deleted once every file is verified. For example:
+nexus-cli promote -g com.example -a myapp -v 1.2.0 --from staging --to releases --delete-source`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		result := &promoteResult{
+			Coordinates: promoteGroup + ":" + promoteArtifact + ":" + promoteVersion,
+			From:        promoteFrom,
+			To:          promoteTo,
+		}
+		assets, err := requireClient().Promote(promoteFrom, promoteTo, promoteGroup, promoteArtifact, promoteVersion, promoteDeleteSource)
+		for _, a := range assets {
+			result.Files = append(result.Files, promotedFile{Path: a.Path, Sha1: a.Sha1, Size: a.Size})
+		}
+		result.SourceDeleted = err == nil && promoteDeleteSource
+		printResult(result)
+		if err != nil {
+			logger.Errorf("Promote Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// promotedFile is a file copied by the promote command
+type promotedFile struct {
+	Path string `json:"path" yaml:"path"`
+	Sha1 string `json:"sha1" yaml:"sha1"`
+	Size int64  `json:"size" yaml:"size"`
+}
+
+// promoteResult is the result of the promote command
+type promoteResult struct {
+	Coordinates   string         `json:"coordinates" yaml:"coordinates"`
+	From          string         `json:"from" yaml:"from"`
+	To            string         `json:"to" yaml:"to"`
+	Files         []promotedFile `json:"files" yaml:"files"`
+	SourceDeleted bool           `json:"sourceDeleted" yaml:"sourceDeleted"`
+}
+
+func (r *promoteResult) printText(w io.Writer) {
+	for _, f := range r.Files {
+		fmt.Fprintf(w, "Promoted %s (sha1 %s)\n", f.Path, f.Sha1)
+	}
+	fmt.Fprintf(w, "Promoted %d files of %s from %s to %s\n", len(r.Files), r.Coordinates, r.From, r.To)
+	if r.SourceDeleted {
+		fmt.Fprintf(w, "Deleted %s from %s\n", r.Coordinates, r.From)
+	}
+}
+
+var promoteGroup, promoteArtifact, promoteVersion string
+var promoteFrom, promoteTo string
+var promoteDeleteSource bool
+
+func init() {
+	RootCmd.AddCommand(promoteCmd)
+	promoteCmd.PersistentFlags().StringVarP(&promoteGroup, "group", "g", "", "The component group id.")
+	promoteCmd.PersistentFlags().StringVarP(&promoteArtifact, "artifact", "a", "", "The component artifact id.")
+	promoteCmd.PersistentFlags().StringVarP(&promoteVersion, "version", "v", "", "The exact component version.")
+	promoteCmd.PersistentFlags().StringVar(&promoteFrom, "from", "", "The source repository. Example: 'staging'")
+	promoteCmd.PersistentFlags().StringVar(&promoteTo, "to", "", "The target repository. Example: 'releases'")
+	promoteCmd.PersistentFlags().BoolVar(&promoteDeleteSource, "delete-source", false, "Delete the component from the source repository once it is verified in the target.")
+	for _, flag := range []string{"group", "artifact", "version", "from", "to"} {
+		promoteCmd.MarkPersistentFlagRequired(flag)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bzon/nexus-cli/raw"
+	"github.com/spf13/cobra"
+)
+
+// rawDownloadCmd represents the raw-download command
+var rawDownloadCmd = &cobra.Command{
+	Use:   "raw-download",
+	Short: "Downloads a Nexus raw repository directory recursively.",
+	Long: `Downloads a Nexus raw repository directory recursively.
+
+The directory structure is kept and every file is verified against its sha1.
+Files that already exist locally with the same sha1 are skipped. For example:
+nexus-cli raw-download -H http://localhost:8081 -r site -t docs/myproject -d /tmp/docs --parallel 8`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		requireRepository(&rawRepository.Name)
+		rawRepository.Client = requireClient()
+		result, err := rawRepository.Download(rawDirectory, rawDestinationDir)
+		r := &rawDownloadResult{Repository: rawRepository.Name, Directory: rawDirectory, Destination: rawDestinationDir}
+		if result != nil {
+			r.Downloaded, r.Skipped = result.Downloaded, result.Skipped
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		printResult(r)
+		if err != nil {
+			logger.Errorf("Raw Download Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// rawDownloadResult is the result of the raw-download command
+type rawDownloadResult struct {
+	Repository  string   `json:"repository" yaml:"repository"`
+	Directory   string   `json:"directory" yaml:"directory"`
+	Destination string   `json:"destination" yaml:"destination"`
+	Downloaded  []string `json:"downloaded" yaml:"downloaded"`
+	Skipped     []string `json:"skipped" yaml:"skipped"`
+	Error       string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (r *rawDownloadResult) printText(w io.Writer) {
+	for _, f := range r.Downloaded {
+		fmt.Fprintln(w, "Downloaded", f)
+	}
+	fmt.Fprintf(w, "%d downloaded, %d already up to date\n", len(r.Downloaded), len(r.Skipped))
+}
+
+var rawRepository raw.Repository
+var rawDirectory, rawDestinationDir string
+
+func init() {
+	RootCmd.AddCommand(rawDownloadCmd)
+	rawDownloadCmd.PersistentFlags().StringVarP(&rawRepository.Name, "repo", "r", "", "nexus raw repository. Defaults to the profile repository.")
+	rawDownloadCmd.PersistentFlags().StringVarP(&rawDirectory, "target", "t", "", "The directory inside the raw repository. Defaults to the whole repository.")
+	cwd, _ := os.Getwd()
+	rawDownloadCmd.PersistentFlags().StringVarP(&rawDestinationDir, "destination", "d", cwd, "The directory where to place the files.")
+	rawDownloadCmd.PersistentFlags().IntVar(&rawRepository.Parallel, "parallel", 4, "The number of parallel downloads.")
+}
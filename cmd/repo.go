@@ -0,0 +1,137 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bzon/nexus-cli/repodiff"
+	"github.com/spf13/cobra"
+)
+
+// repoCmd represents the repo command
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Inspects whole repositories.",
+}
+
+// repoDiffCmd represents the repo diff command
+var repoDiffCmd = &cobra.Command{
+	Use:   "diff [PROFILE:]REPOSITORY [PROFILE:]REPOSITORY",
+	Short: "Compares the components and checksums of two repositories.",
+	Long: `Compares the components and checksums of two repositories.
+
+Each repository is read from the server of a profile when prefixed with 'PROFILE:', and
+from the server of the host flags or --profile otherwise. The Nexus version of a side is the
+version of its profile or --nexus-version, detected from the server when unset. Components missing on either side, assets
+missing from a component and assets with different checksums are reported. Maven metadata
+and checksum files are ignored. The exit code is 1 when the repositories differ. For example:
+nexus-cli repo diff nexus2:releases nexus3:maven-releases -o json`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		left, leftAssets, err := listRepository(args[0])
+		if err != nil {
+			logger.Errorf("Repo Diff Error: %s: %v", args[0], err)
+			os.Exit(1)
+		}
+		right, rightAssets, err := listRepository(args[1])
+		if err != nil {
+			logger.Errorf("Repo Diff Error: %s: %v", args[1], err)
+			os.Exit(1)
+		}
+		result := repodiff.Compare(leftAssets, rightAssets)
+		result.Left, result.Right = left, right
+		printResult(&repoDiffResult{*result})
+		if !result.Equal() {
+			os.Exit(1)
+		}
+	},
+}
+
+// listRepository lists the assets of a [PROFILE:]REPOSITORY argument and returns the url of the repository
+func listRepository(spec string) (string, []repodiff.Asset, error) {
+	var s server
+	repository := spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		var err error
+		if s, err = profileServer(spec[:i]); err != nil {
+			return "", nil, err
+		}
+		repository = spec[i+1:]
+	} else {
+		requireServer()
+		s = server{Profile: profile, password: NexusPassword, auth: serverAuth, client: serverHTTPClient}
+		s.Host, s.Username, s.Version = NexusHostURL, NexusUsername, requireNexusVersion()
+	}
+	if repository == "" {
+		return "", nil, fmt.Errorf("no repository")
+	}
+
+	client, err := s.nexusClient()
+	if err != nil {
+		return "", nil, err
+	}
+	logger.Infof("Listing %s on %s", repository, s.Host)
+	assets, err := repodiff.List(client, repository, repoDiffParallel)
+	return client.URL(repository), assets, err
+}
+
+// repoDiffResult is the result of the repo diff command
+type repoDiffResult struct {
+	repodiff.Result `yaml:",inline"`
+}
+
+func (r *repoDiffResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "--- %s (%d assets)\n", r.Left, r.LeftAssets)
+	fmt.Fprintf(w, "+++ %s (%d assets)\n", r.Right, r.RightAssets)
+	for _, c := range r.OnlyLeft {
+		fmt.Fprintln(w, "- component", c)
+	}
+	for _, c := range r.OnlyRight {
+		fmt.Fprintln(w, "+ component", c)
+	}
+	for _, p := range r.AssetsOnlyLeft {
+		fmt.Fprintln(w, "- asset", p)
+	}
+	for _, p := range r.AssetsOnlyRight {
+		fmt.Fprintln(w, "+ asset", p)
+	}
+	for _, c := range r.Changed {
+		fmt.Fprintf(w, "! asset %s (sha1 %s != %s)\n", c.Path, c.LeftSha1, c.RightSha1)
+	}
+	if r.Equal() {
+		fmt.Fprintf(w, "The repositories are identical (%d assets)\n", r.Identical)
+		return
+	}
+	fmt.Fprintf(w, "%d components only left, %d only right, %d assets only left, %d only right, %d changed, %d identical\n",
+		len(r.OnlyLeft), len(r.OnlyRight), len(r.AssetsOnlyLeft), len(r.AssetsOnlyRight), len(r.Changed), r.Identical)
+}
+
+var repoDiffParallel int
+
+func init() {
+	RootCmd.AddCommand(repoCmd)
+	repoCmd.AddCommand(repoDiffCmd)
+	repoDiffCmd.PersistentFlags().IntVar(&repoDiffParallel, "parallel", 4, "The number of Nexus 2 checksums asked at a time.")
+}
@@ -23,7 +23,17 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/logging"
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/nexus2"
+	"github.com/bzon/nexus-cli/nexus3"
+	"github.com/bzon/nexus-cli/raw"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -42,7 +52,7 @@ var RootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -56,40 +66,143 @@ var NexusUsername string
 // NexusPassword may be required for nexus authentication
 var NexusPassword string
 
+// NexusToken is the token of bearer authentication
+var NexusToken string
+
+// authMethod is the --auth flag
+var authMethod string
+
+// netrcFile is the --netrc-file flag
+var netrcFile string
+
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initLogging, initConfig, initOutput)
 
 	RootCmd.PersistentFlags().StringVarP(&NexusHostURL, "hostURL", "H", "", "The Nexus host url including the protocol. Defaults to Env $NEXUS_HOST.")
-	RootCmd.PersistentFlags().StringVarP(&NexusUsername, "username", "U", "", "The Nexus host url including the protocol. Defaults to Env $NEXUS_USERNAME.")
-	RootCmd.PersistentFlags().StringVarP(&NexusPassword, "password", "P", "", "The Nexus host url including the protocol. Defaults to Env $NEXUS_PASSWORD")
+	RootCmd.PersistentFlags().StringVarP(&NexusUsername, "username", "U", "", "The Nexus username. Defaults to Env $NEXUS_USERNAME.")
+	RootCmd.PersistentFlags().StringVarP(&NexusPassword, "password", "P", "", "The Nexus password. Defaults to Env $NEXUS_PASSWORD")
+	RootCmd.PersistentFlags().StringVar(&NexusToken, "token", "", "The bearer token. Defaults to Env $NEXUS_TOKEN")
+	RootCmd.PersistentFlags().StringVar(&authMethod, "auth", "", "The authentication: anonymous, basic, netrc, user-token or bearer. user-token takes the token name and passcode from --username and --password.")
+	RootCmd.PersistentFlags().StringVar(&netrcFile, "netrc-file", "", "The netrc file of --auth netrc (default is $NETRC or $HOME/.netrc)")
 
 	// Lookup for the Environment variables
-	if h := os.Getenv("NEXUS_HOST"); h == "" {
-		RootCmd.MarkPersistentFlagRequired("hostURL")
-	} else {
-		NexusHostURL = h
-	}
-	if u := os.Getenv("NEXUS_USERNAME"); u == "" {
-		RootCmd.MarkPersistentFlagRequired("password")
-	} else {
-		NexusUsername = u
-	}
-	if p := os.Getenv("NEXUS_PASSWORD"); p == "" {
-		RootCmd.MarkPersistentFlagRequired("username")
-	} else {
-		NexusPassword = p
-	}
+	// The flags are checked by requireServer so that commands can fill them from other sources first.
+	// Values still empty after flags and environment variables are taken from the config file profile
+	// and then from the Maven settings.xml server.
+	NexusHostURL = os.Getenv("NEXUS_HOST")
+	NexusUsername = os.Getenv("NEXUS_USERNAME")
+	NexusPassword = os.Getenv("NEXUS_PASSWORD")
+	NexusToken = os.Getenv("NEXUS_TOKEN")
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
+	RootCmd.PersistentFlags().IntVar(&nexusVersion, "nexus-version", 0, "The Nexus major version, 2 or 3. Defaults to the profile version, and is detected from the server when neither is set.")
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.nexuscli.yaml)")
+	RootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "The config file profile to use. Defaults to Env $NEXUS_PROFILE or the current-profile of the config file.")
+	RootCmd.PersistentFlags().StringVar(&serverID, "server-id", "", "Read the credentials of this <server> id from the Maven settings.xml. A <mirror> with the same id supplies the host url.")
+	RootCmd.PersistentFlags().StringVar(&mavenSettings, "maven-settings", "", "The Maven settings file (default is $HOME/.m2/settings.xml)")
+	RootCmd.PersistentFlags().StringVar(&mavenSettingsSecurity, "maven-settings-security", "", "The Maven settings-security.xml file holding the master password, like -Dsettings.security (default is $HOME/.m2/settings-security.xml)")
+	RootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "The output format of the command result: text, json or yaml.")
+	RootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Log debug messages such as request urls and checksums.")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log warnings and errors.")
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "The format of the logs written to stderr: text or json.")
+	RootCmd.PersistentFlags().BoolVar(&httpDebug, "http-debug", false, "Log the headers of every request and response. The Authorization header is redacted.")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 
 }
 
+// serverAuth authenticates the requests of the commands. It is set by requireServer.
+var serverAuth auth.Authenticator
+
+// requireServer fills the missing credentials from the login store, creates serverAuth
+// and exits when the Nexus host url or the credentials of the authentication method are not set.
+// Without --auth, basic authentication is used when a username or password is set,
+// bearer authentication when a token is set and anonymous access otherwise.
+func requireServer() {
+	method := authMethod
+	if method == "" {
+		method = profile.Auth
+	}
+	if method == "" || method == "basic" || method == "user-token" {
+		applyStoredCredentials()
+	}
+	if method == "" {
+		method = defaultAuthMethod(NexusUsername, NexusPassword, NexusToken)
+	}
+	type flag struct{ name, value string }
+	required := []flag{{"hostURL", NexusHostURL}}
+	switch method {
+	case "basic", "user-token":
+		required = append(required, flag{"username", NexusUsername}, flag{"password", NexusPassword})
+	case "bearer":
+		required = append(required, flag{"token", NexusToken})
+	}
+	var missing []string
+	for _, f := range required {
+		if f.value == "" {
+			missing = append(missing, `"`+f.name+`"`)
+		}
+	}
+	if len(missing) > 0 {
+		logger.Errorf("Error: required flag(s) %s not set", strings.Join(missing, ", "))
+		os.Exit(1)
+	}
+	var err error
+	if serverAuth, err = auth.New(method, NexusUsername, NexusPassword, NexusToken, netrcFile); err != nil {
+		logger.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	logger.Debugf("Using %s authentication", method)
+}
+
+// defaultAuthMethod returns the authentication method used without --auth: basic when a username
+// or password is set, bearer when a token is set, and anonymous otherwise
+func defaultAuthMethod(username, password, token string) string {
+	switch {
+	case username != "" || password != "":
+		return "basic"
+	case token != "":
+		return "bearer"
+	default:
+		return "anonymous"
+	}
+}
+
+// logger receives the human readable logs of the commands
+var logger, _ = logging.New(os.Stderr, logging.LevelInfo, "text")
+
+var verbose, quiet, httpDebug bool
+var logFormat string
+
+// initLogging creates the logger from --verbose, --quiet, --log-format and --http-debug
+// and hands it to the nexus2 and nexus3 packages
+func initLogging() {
+	if verbose && quiet {
+		logger.Errorf("Error: --verbose and --quiet cannot be used together")
+		os.Exit(1)
+	}
+	level := logging.LevelInfo
+	switch {
+	case verbose || httpDebug:
+		level = logging.LevelDebug
+	case quiet:
+		level = logging.LevelWarn
+	}
+	l, err := logging.New(os.Stderr, level, logFormat)
+	if err != nil {
+		logger.Errorf("Error: %v", err)
+		os.Exit(1)
+	}
+	logger = l
+	nexus.SetLogger(l)
+	nexus2.SetLogger(l)
+	nexus3.SetLogger(l)
+	raw.SetLogger(l)
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgFile != "" {
@@ -99,7 +212,7 @@ func initConfig() {
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			fmt.Println(err)
+			logger.Errorf("%v", err)
 			os.Exit(1)
 		}
 
@@ -112,6 +225,25 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		logger.Debugf("Using config file: %s", viper.ConfigFileUsed())
+	}
+
+	// Apply the selected profile
+	for _, step := range []func() error{loadProfile, applyProfile, applyMavenSettings, initHTTP, initCache} {
+		if err := step(); err != nil {
+			logger.Errorf("Error: %v", err)
+			os.Exit(1)
+		}
+	}
+	if activeProfile != "" {
+		logger.Debugf("Using profile %s", activeProfile)
+	}
+}
+
+// initOutput validates --output and turns colours off when stdout is not a terminal
+func initOutput() {
+	checkOutputFormat()
+	if !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
+		color.NoColor = true
 	}
 }
@@ -0,0 +1,103 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bzon/nexus-cli/raw"
+	"github.com/spf13/cobra"
+)
+
+// sitePublishCmd represents the site-publish command
+var sitePublishCmd = &cobra.Command{
+	Use:   "site-publish",
+	Short: "Publishes a versioned site to a Nexus raw repository.",
+	Long: `Publishes a versioned site to a Nexus raw repository.
+
+The site is uploaded to <target>/<version>/, the version is added to
+<target>/versions.json, <target>/index.html lists all versions and
+<target>/latest/ is pointed at the new version unless a newer one is published. For example:
+nexus-cli site-publish -H http://localhost:8081 -r site -s public/ -t site/myproject --version 1.2.0
+
+Use --latest copy to copy the whole tree into latest/ instead of writing a redirect page.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		requireRepository(&publishRepository.Name)
+		publishRepository.Client = requireClient()
+		switch publishLatest {
+		case "redirect":
+		case "copy":
+			publication.CopyLatest = true
+		default:
+			logger.Errorf("Site Publish Error: --latest must be 'redirect' or 'copy', got %q", publishLatest)
+			os.Exit(1)
+		}
+		versions, err := publishRepository.PublishSite(publication)
+		if err != nil {
+			logger.Errorf("Site Publish Error: %v", err)
+			os.Exit(1)
+		}
+		directory := strings.Trim(publication.Directory, "/")
+		printResult(&sitePublishResult{
+			Repository: publishRepository.Name,
+			Directory:  directory,
+			Version:    publication.Version,
+			URL:        publishRepository.URL() + "/" + directory + "/" + publication.Version + "/",
+			Latest:     versions.Latest,
+			Versions:   versions.Versions,
+		})
+	},
+}
+
+// sitePublishResult is the result of the site-publish command
+type sitePublishResult struct {
+	Repository string   `json:"repository" yaml:"repository"`
+	Directory  string   `json:"directory" yaml:"directory"`
+	Version    string   `json:"version" yaml:"version"`
+	URL        string   `json:"url" yaml:"url"`
+	Latest     string   `json:"latest" yaml:"latest"`
+	Versions   []string `json:"versions" yaml:"versions"`
+}
+
+func (r *sitePublishResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Successfully published %s to %s\n", r.Version, r.URL)
+	fmt.Fprintln(w, "Published versions:", strings.Join(r.Versions, ", "))
+}
+
+var publishRepository = raw.Repository{Parallel: 4}
+var publication raw.SitePublication
+var publishLatest string
+
+func init() {
+	RootCmd.AddCommand(sitePublishCmd)
+	sitePublishCmd.PersistentFlags().StringVarP(&publishRepository.Name, "repo", "r", "", "nexus site raw repository. Defaults to the profile repository.")
+	sitePublishCmd.PersistentFlags().StringVarP(&publication.Source, "source", "s", "", "The local directory holding the generated site.")
+	sitePublishCmd.PersistentFlags().StringVarP(&publication.Directory, "target", "t", "", "The project directory inside the raw repository. Example: 'site/myproject'")
+	sitePublishCmd.PersistentFlags().StringVar(&publication.Version, "version", "", "The version to publish.")
+	sitePublishCmd.PersistentFlags().StringVar(&publishLatest, "latest", "redirect", "How to write the latest/ alias: 'redirect' or 'copy'.")
+	sitePublishCmd.MarkPersistentFlagRequired("source")
+	sitePublishCmd.MarkPersistentFlagRequired("target")
+	sitePublishCmd.MarkPersistentFlagRequired("version")
+}
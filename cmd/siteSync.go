@@ -0,0 +1,150 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bzon/nexus-cli/raw"
+	"github.com/spf13/cobra"
+)
+
+// siteSyncCmd represents the site-sync command
+var siteSyncCmd = &cobra.Command{
+	Use:   "site-sync",
+	Short: "Synchronises a local directory with a Nexus raw repository directory.",
+	Long: `Synchronises a local directory with a Nexus raw repository directory.
+
+Only the files whose sha1 differ from the remote assets are uploaded. Remote
+files that no longer exist locally are deleted when --prune is given.
+The plan is always printed first. For example:
+nexus-cli site-sync -H http://localhost:8081 -r site -s public/ -t docs/myproject --prune --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		requireRepository(&syncRepository.Name)
+		// pruning against the repository root would delete every file that is not in the source
+		if syncPrune && strings.Trim(syncDirectory, "/") == "" {
+			logger.Errorf("Site Sync Error: --prune needs a --target directory")
+			os.Exit(1)
+		}
+		syncRepository.Client = requireClient()
+		plan, err := syncRepository.PlanSync(syncSource, syncDirectory)
+		if err != nil {
+			logger.Errorf("Site Sync Error: %v", err)
+			os.Exit(1)
+		}
+		result := newSiteSyncResult(plan)
+		// the text plan is printed before anything changes
+		if outputFormat == "text" {
+			printResult(result)
+		}
+		if !syncDryRun {
+			if err := syncRepository.ApplySync(plan, syncPrune); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Applied = true
+				logger.Infof("Successfully synchronised %s to %s/%s", syncSource, syncRepository.URL(), syncDirectory)
+			}
+		}
+		if outputFormat != "text" {
+			printResult(result)
+		}
+		if result.Error != "" {
+			logger.Errorf("Site Sync Error: %v", result.Error)
+			os.Exit(1)
+		}
+	},
+}
+
+// siteSyncResult is the result of the site-sync command
+type siteSyncResult struct {
+	Repository string   `json:"repository" yaml:"repository"`
+	Directory  string   `json:"directory" yaml:"directory"`
+	Source     string   `json:"source" yaml:"source"`
+	Added      []string `json:"added" yaml:"added"`
+	Updated    []string `json:"updated" yaml:"updated"`
+	Stale      []string `json:"stale" yaml:"stale"`
+	Unchanged  int      `json:"unchanged" yaml:"unchanged"`
+	Prune      bool     `json:"prune" yaml:"prune"`
+	DryRun     bool     `json:"dryRun" yaml:"dryRun"`
+	Applied    bool     `json:"applied" yaml:"applied"`
+	Error      string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func newSiteSyncResult(plan *raw.SyncPlan) *siteSyncResult {
+	r := &siteSyncResult{
+		Repository: syncRepository.Name,
+		Directory:  syncDirectory,
+		Source:     syncSource,
+		Added:      []string{},
+		Updated:    []string{},
+		Stale:      []string{},
+		Unchanged:  len(plan.Unchanged),
+		Prune:      syncPrune,
+		DryRun:     syncDryRun,
+	}
+	for _, c := range plan.Added {
+		r.Added = append(r.Added, c.Filename)
+	}
+	for _, c := range plan.Changed {
+		r.Updated = append(r.Updated, c.Filename)
+	}
+	for _, a := range plan.Stale {
+		r.Stale = append(r.Stale, a.Path)
+	}
+	return r
+}
+
+// printText prints the plan
+func (r *siteSyncResult) printText(w io.Writer) {
+	for _, f := range r.Added {
+		fmt.Fprintln(w, "+ add    ", f)
+	}
+	for _, f := range r.Updated {
+		fmt.Fprintln(w, "~ update ", f)
+	}
+	for _, f := range r.Stale {
+		if r.Prune {
+			fmt.Fprintln(w, "- delete ", f)
+		} else {
+			fmt.Fprintln(w, "! stale  ", f)
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to add, %d to update, %d unchanged, %d stale\n",
+		len(r.Added), len(r.Updated), r.Unchanged, len(r.Stale))
+}
+
+var syncRepository = raw.Repository{Parallel: 4}
+var syncSource, syncDirectory string
+var syncPrune, syncDryRun bool
+
+func init() {
+	RootCmd.AddCommand(siteSyncCmd)
+	siteSyncCmd.PersistentFlags().StringVarP(&syncRepository.Name, "repo", "r", "", "nexus site raw repository. Defaults to the profile repository.")
+	siteSyncCmd.PersistentFlags().StringVarP(&syncSource, "source", "s", "", "The local directory to synchronise.")
+	siteSyncCmd.PersistentFlags().StringVarP(&syncDirectory, "target", "t", "", "The directory inside the raw repository.")
+	siteSyncCmd.PersistentFlags().BoolVar(&syncPrune, "prune", false, "Delete remote files that no longer exist locally. Requires --target.")
+	siteSyncCmd.PersistentFlags().BoolVar(&syncDryRun, "dry-run", false, "Only print the plan.")
+	siteSyncCmd.MarkPersistentFlagRequired("source")
+}
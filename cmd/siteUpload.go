@@ -16,28 +16,57 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 
-	"github.com/bzon/nexus-cli/nexus3"
+	"github.com/bzon/nexus-cli/raw"
 	"github.com/spf13/cobra"
 )
 
-var site nexus3.Client
+var site raw.Repository
+var siteFile raw.File
 
 // siteUploadCmd represents the siteUpload command
 var siteUploadCmd = &cobra.Command{
 	Use:   "site-upload",
-	Short: "A brief description of your command",
+	Short: "Uploads a single file to a Nexus raw repository.",
+	Long: `Uploads a single file to a Nexus raw repository.
+
+On Nexus 2 the file is stored through the content url of a hosted repository. For example:
+nexus-cli site-upload -H http://localhost:8081 -r site -f build/report.html -t reports/myproject`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(site.HostURL)
+		requireServer()
+		requireRepository(&site.Name)
+		site.Client = requireClient()
+		if siteFile.Filename == "" {
+			siteFile.Filename = filepath.Base(siteFile.File)
+		}
+		uri, err := site.Upload(siteFile)
+		if err != nil {
+			logger.Errorf("Site Upload Error: %v", err)
+			os.Exit(1)
+		}
+		printResult(&siteUploadResult{Repository: site.Name, File: siteFile.File, URL: uri})
 	},
 }
 
+// siteUploadResult is the result of the site-upload command
+type siteUploadResult struct {
+	Repository string `json:"repository" yaml:"repository"`
+	File       string `json:"file" yaml:"file"`
+	URL        string `json:"url" yaml:"url"`
+}
+
+func (r *siteUploadResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Successfully uploaded %s to %s\n", r.File, r.URL)
+}
+
 func init() {
 	RootCmd.AddCommand(siteUploadCmd)
-	siteUploadCmd.PersistentFlags().StringVarP(&site.Repository, "repo", "r", "", "nexus 3 site raw repository")
-	siteUploadCmd.MarkPersistentFlagRequired("repo")
-	fmt.Println(NexusHostURL)
-	site.HostURL = NexusHostURL
-	site.Username = NexusUsername
-	site.Password = NexusPassword
+	siteUploadCmd.PersistentFlags().StringVarP(&site.Name, "repo", "r", "", "nexus site raw repository. Defaults to the profile repository.")
+	siteUploadCmd.PersistentFlags().StringVarP(&siteFile.File, "file", "f", "", "The local file to upload.")
+	siteUploadCmd.PersistentFlags().StringVarP(&siteFile.Directory, "target", "t", "", "The directory inside the raw repository.")
+	siteUploadCmd.PersistentFlags().StringVar(&siteFile.Filename, "filename", "", "The remote file name. Defaults to the local file name.")
+	siteUploadCmd.MarkPersistentFlagRequired("file")
 }
@@ -0,0 +1,159 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bzon/nexus-cli/snapshots"
+	"github.com/spf13/cobra"
+)
+
+// snapshotsCmd represents the snapshots command
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Manages the timestamped builds of a snapshots repository.",
+}
+
+// snapshotsPurgeCmd represents the snapshots purge command
+var snapshotsPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Deletes the old timestamped builds of every snapshot version.",
+	Long: `Deletes the old timestamped builds of every snapshot version.
+
+With --keep, the newest builds of every group:artifact:version are kept. With --older-than,
+the builds deployed within that duration are kept. With both, a build is deleted only when
+neither keeps it. --include and --exclude select groups, com.example also matching the groups
+below it. Nexus 2 builds are read from the -SNAPSHOT directories, whose maven-metadata.xml
+is rebuilt once their builds are deleted, Nexus 3 builds from the search API. For example:
+nexus-cli snapshots purge -r snapshots --keep 3 --older-than 30d --include com.example --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		requireRepository(&purgeRepository)
+		olderThan, err := parseAge(purgeOlderThan)
+		if err != nil {
+			logger.Errorf("Error: --older-than: %v", err)
+			os.Exit(1)
+		}
+		if purgeKeep <= 0 && olderThan == 0 {
+			logger.Errorf("Error: set --keep or --older-than")
+			os.Exit(1)
+		}
+		client := requireClient()
+
+		logger.Infof("Listing the snapshot builds of %s", purgeRepository)
+		builds, err := snapshots.Builds(client, purgeRepository)
+		if err != nil {
+			logger.Errorf("Snapshots Error: %v", err)
+			os.Exit(1)
+		}
+		policy := snapshots.Policy{Keep: purgeKeep, OlderThan: olderThan, Now: time.Now()}
+		filter := snapshots.Filter{Include: purgeInclude, Exclude: purgeExclude}
+		result := &snapshotsPurgeResult{Repository: purgeRepository, Builds: len(builds), DryRun: purgeDryRun}
+		for _, b := range snapshots.Plan(builds, policy, filter) {
+			if !purgeDryRun {
+				logger.Infof("Deleting %s:%s:%s", b.Group, b.Artifact, b.Version)
+				if err := snapshots.Delete(client, purgeRepository, b); err != nil {
+					logger.Errorf("Failed deleting %s:%s:%s: %v", b.Group, b.Artifact, b.Version, err)
+					result.Failed = append(result.Failed, purgeFailure{Build: b, Error: err.Error()})
+					continue
+				}
+			}
+			result.Purged = append(result.Purged, b)
+			result.Size += b.Size
+		}
+		// Nexus 3 keeps the metadata up to date itself, its rebuild does nothing
+		if !purgeDryRun {
+			rebuilt := map[string]bool{}
+			for _, b := range result.Purged {
+				if rebuilt[b.GAV()] {
+					continue
+				}
+				rebuilt[b.GAV()] = true
+				logger.Debugf("Rebuilding the metadata of %s", b.GAV())
+				if err := snapshots.RebuildMetadata(client, purgeRepository, b); err != nil {
+					logger.Errorf("Failed rebuilding the metadata of %s: %v", b.GAV(), err)
+					result.StaleMetadata = append(result.StaleMetadata, b.GAV())
+				}
+			}
+		}
+		printResult(result)
+		if len(result.Failed) > 0 || len(result.StaleMetadata) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// purgeFailure is a build that could not be deleted
+type purgeFailure struct {
+	snapshots.Build `yaml:",inline"`
+	Error           string `json:"error" yaml:"error"`
+}
+
+// snapshotsPurgeResult is the result of the snapshots purge command
+type snapshotsPurgeResult struct {
+	Repository string `json:"repository" yaml:"repository"`
+	// Builds counts the snapshot builds of the repository
+	Builds int               `json:"builds" yaml:"builds"`
+	Purged []snapshots.Build `json:"purged" yaml:"purged"`
+	Failed []purgeFailure    `json:"failed" yaml:"failed"`
+	// StaleMetadata lists the Nexus 2 snapshot versions whose maven-metadata.xml could not be rebuilt
+	StaleMetadata []string `json:"staleMetadata,omitempty" yaml:"staleMetadata,omitempty"`
+	// Size is the space freed by the purged builds
+	Size   int64 `json:"size" yaml:"size"`
+	DryRun bool  `json:"dryRun" yaml:"dryRun"`
+}
+
+func (r *snapshotsPurgeResult) printText(w io.Writer) {
+	verb := "Deleted"
+	if r.DryRun {
+		verb = "Would delete"
+	}
+	for _, b := range r.Purged {
+		fmt.Fprintf(w, "%s %s:%s:%s (%s, %s)\n", verb, b.Group, b.Artifact, b.Version, b.Timestamp.Format("2006-01-02 15:04"), formatSize(b.Size))
+	}
+	for _, f := range r.Failed {
+		fmt.Fprintf(w, "Failed deleting %s:%s:%s: %s\n", f.Group, f.Artifact, f.Version, f.Error)
+	}
+	for _, gav := range r.StaleMetadata {
+		fmt.Fprintln(w, "Failed rebuilding the metadata of", gav)
+	}
+	fmt.Fprintf(w, "%s %d of %d snapshot builds in %s, freeing %s\n", verb, len(r.Purged), r.Builds, r.Repository, formatSize(r.Size))
+}
+
+var purgeRepository, purgeOlderThan string
+var purgeKeep int
+var purgeInclude, purgeExclude []string
+var purgeDryRun bool
+
+func init() {
+	RootCmd.AddCommand(snapshotsCmd)
+	snapshotsCmd.AddCommand(snapshotsPurgeCmd)
+	snapshotsPurgeCmd.PersistentFlags().StringVarP(&purgeRepository, "repo", "r", "", "The snapshots repository. Example: 'snapshots'")
+	snapshotsPurgeCmd.PersistentFlags().IntVar(&purgeKeep, "keep", 0, "Keep the newest N builds of every snapshot version.")
+	snapshotsPurgeCmd.PersistentFlags().StringVar(&purgeOlderThan, "older-than", "", "Only delete the builds deployed before this duration, for example 30d or 72h.")
+	snapshotsPurgeCmd.PersistentFlags().StringSliceVar(&purgeInclude, "include", nil, "Only purge these groups and the groups below them. Wildcards are allowed. Example: com.example,org.acme.*")
+	snapshotsPurgeCmd.PersistentFlags().StringSliceVar(&purgeExclude, "exclude", nil, "Never purge these groups and the groups below them.")
+	snapshotsPurgeCmd.PersistentFlags().BoolVar(&purgeDryRun, "dry-run", false, "Only print the builds that would be deleted and the space freed.")
+}
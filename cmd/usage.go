@@ -0,0 +1,139 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/usage"
+	"github.com/spf13/cobra"
+)
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Reports the storage used by repository, group, artifact and version.",
+	Long: `Reports the storage used by repository, group, artifact and version.
+
+Every asset of the repositories is listed, through the content listing of Nexus 2 or
+the assets API of Nexus 3, and the sizes are added up. The --top largest entries of each
+level are printed, and --csv appends every entry as a dated row to a file. For example:
+nexus-cli usage -r releases,snapshots --top 20 --group-depth 2 --csv usage.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		requireServer()
+		if len(usageRepositories) == 0 && profile.Repository != "" {
+			usageRepositories = []string{profile.Repository}
+		}
+		if len(usageRepositories) == 0 {
+			logger.Errorf(`Error: required flag(s) "repo" not set`)
+			os.Exit(1)
+		}
+		client := requireClient()
+		var assets []usage.Asset
+		for _, repository := range usageRepositories {
+			logger.Infof("Listing %s", repository)
+			listed, err := listUsage(client, repository)
+			if err != nil {
+				logger.Errorf("Usage Error: %s: %v", repository, err)
+				os.Exit(1)
+			}
+			assets = append(assets, listed...)
+		}
+		report := usage.Summarize(assets, usageGroupDepth, time.Now())
+		if usageCSV != "" {
+			if err := appendCSV(report, usageCSV); err != nil {
+				logger.Errorf("Usage Error: %v", err)
+				os.Exit(1)
+			}
+		}
+		printResult(&usageResult{*report.Top(usageTop)})
+	},
+}
+
+// listUsage lists the assets of a repository with their size
+func listUsage(client nexus.Client, repository string) ([]usage.Asset, error) {
+	list, err := client.List(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]usage.Asset, 0, len(list))
+	for _, a := range list {
+		assets = append(assets, usage.Asset{Repository: repository, Path: a.Path, Size: a.Size})
+	}
+	return assets, nil
+}
+
+// appendCSV appends the rows of a report to a CSV file, with a header when the file is new
+func appendCSV(report *usage.Report, path string) error {
+	info, err := os.Stat(path)
+	header := os.IsNotExist(err) || (err == nil && info.Size() == 0)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteCSV(f, header); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// usageResult is the result of the usage command
+type usageResult struct {
+	usage.Report `yaml:",inline"`
+}
+
+func (r *usageResult) printText(w io.Writer) {
+	fmt.Fprintf(w, "Total: %s in %d files\n", formatSize(r.Size), r.Files)
+	for _, level := range []struct {
+		title   string
+		entries []usage.Entry
+	}{
+		{"Repositories", r.Repositories},
+		{"Groups", r.Groups},
+		{"Artifacts", r.Artifacts},
+		{"Versions", r.Versions},
+	} {
+		if len(level.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", level.title)
+		for _, e := range level.entries {
+			fmt.Fprintf(w, "  %10s  %6d files  %s\n", formatSize(e.Size), e.Files, e.Name)
+		}
+	}
+}
+
+var usageRepositories []string
+var usageTop, usageGroupDepth int
+var usageCSV string
+
+func init() {
+	RootCmd.AddCommand(usageCmd)
+	usageCmd.PersistentFlags().StringSliceVarP(&usageRepositories, "repo", "r", nil, "The repositories to report on. Example: releases,snapshots")
+	usageCmd.PersistentFlags().IntVar(&usageTop, "top", 10, "Print the N largest entries of every level, 0 for all of them.")
+	usageCmd.PersistentFlags().IntVar(&usageGroupDepth, "group-depth", 2, "Add up the groups by their first N elements, for example com.example for 2.")
+	usageCmd.PersistentFlags().StringVar(&usageCSV, "csv", "", "Append every entry of the report as a dated row to this CSV file.")
+}
@@ -0,0 +1,179 @@
+// Package credentials keeps the Nexus credentials saved by 'nexus-cli login' in an encrypted file.
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// PassphraseEnv is the environment variable holding the passphrase of the store
+	PassphraseEnv = "NEXUS_CLI_PASSPHRASE"
+	// KeyFileEnv is the environment variable naming a key file whose content is the passphrase of the store
+	KeyFileEnv = "NEXUS_CLI_KEY_FILE"
+)
+
+// ErrNoKey is returned by KeyFromEnv when neither PassphraseEnv nor KeyFileEnv is set
+var ErrNoKey = fmt.Errorf("set $%s or $%s to encrypt the stored credentials", PassphraseEnv, KeyFileEnv)
+
+// ErrWrongKey is returned by Open when the store cannot be decrypted with the key
+var ErrWrongKey = errors.New("the stored credentials cannot be decrypted, wrong passphrase or key file")
+
+// Credential is the saved login of a Nexus server
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Store holds the credentials of every server by host url
+type Store struct {
+	Path        string
+	Credentials map[string]Credential
+
+	key []byte
+}
+
+// file is the encrypted form of a Store on disk
+type file struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// DefaultPath returns the credentials file of the user config directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nexus-cli", "credentials.json"), nil
+}
+
+// KeyFromEnv returns the passphrase of $NEXUS_CLI_PASSPHRASE or the content of the $NEXUS_CLI_KEY_FILE file
+func KeyFromEnv() ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return nil, fmt.Errorf("%s: the key file is empty", path)
+		}
+		return b, nil
+	}
+	return nil, ErrNoKey
+}
+
+// Open reads and decrypts a store. A missing file is an empty store.
+func Open(path string, key []byte) (*Store, error) {
+	s := &Store{Path: path, Credentials: map[string]Credential{}, key: key}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("%s: unsupported version %d", path, f.Version)
+	}
+	aead, err := newAEAD(key, f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	if err := json.Unmarshal(plain, &s.Credentials); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the credential of a host url
+func (s *Store) Get(host string) (Credential, bool) {
+	c, found := s.Credentials[normalize(host)]
+	return c, found
+}
+
+// Set saves the credential of a host url in memory, call Save to write it
+func (s *Store) Set(host string, c Credential) {
+	s.Credentials[normalize(host)] = c
+}
+
+// Delete removes the credential of a host url and reports whether there was one
+func (s *Store) Delete(host string) bool {
+	host = normalize(host)
+	_, found := s.Credentials[host]
+	delete(s.Credentials, host)
+	return found
+}
+
+// Save encrypts the store with a new salt and writes it, readable by the owner only
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.Credentials)
+	if err != nil {
+		return err
+	}
+	f := file{Version: 1, Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(s.key, f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, plain, nil)
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+// newAEAD derives an AES-256-GCM cipher from the key with scrypt
+func newAEAD(key, salt []byte) (cipher.AEAD, error) {
+	derived, err := scrypt.Key(key, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func normalize(host string) string {
+	return strings.TrimSuffix(host, "/")
+}
@@ -0,0 +1,46 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nexus-cli", "credentials.json")
+
+	s, err := Open(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("http://localhost:8081/", Credential{Username: "admin", Password: "admin123"})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(b), "admin123") {
+		t.Error("the password is stored in clear text")
+	}
+
+	s, err = Open(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, found := s.Get("http://localhost:8081"); !found || c.Password != "admin123" {
+		t.Errorf("got %+v, %v", c, found)
+	}
+	if !s.Delete("http://localhost:8081") || s.Delete("http://localhost:8081") {
+		t.Error("Delete should only report the first removal")
+	}
+
+	if _, err := Open(path, []byte("wrong")); err != ErrWrongKey {
+		t.Errorf("got %v, want ErrWrongKey", err)
+	}
+}
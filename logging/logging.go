@@ -0,0 +1,110 @@
+// Package logging provides the leveled logger used by the nexus2 and nexus3 packages.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Logger is implemented by anything that can receive the leveled log messages of nexus-cli
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Level is the minimum level of the messages written by a logger
+type Level int
+
+const (
+	// LevelDebug logs everything, including requests and checksums
+	LevelDebug Level = iota
+	// LevelInfo logs progress messages
+	LevelInfo
+	// LevelWarn logs warnings and errors
+	LevelWarn
+	// LevelError only logs errors
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// Discard is a logger dropping every message
+var Discard Logger = discard{}
+
+type discard struct{}
+
+func (discard) Debugf(string, ...interface{}) {}
+func (discard) Infof(string, ...interface{})  {}
+func (discard) Warnf(string, ...interface{})  {}
+func (discard) Errorf(string, ...interface{}) {}
+
+// New returns a logger writing the messages at or above level to w.
+// The format is "text" for human readable lines or "json" for one JSON object per line.
+func New(w io.Writer, level Level, format string) (Logger, error) {
+	switch format {
+	case "text", "json":
+		return &writer{w: w, level: level, json: format == "json"}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+}
+
+// writer is the Logger returned by New
+type writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+	json  bool
+}
+
+var levelColors = map[Level]*color.Color{
+	LevelWarn:  color.New(color.FgYellow),
+	LevelError: color.New(color.FgRed),
+}
+
+func (l *writer) log(level Level, format string, args ...interface{}) {
+	if level < l.level {
+		return
+	}
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.json {
+		b, _ := json.Marshal(struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{time.Now().Format(time.RFC3339), level.String(), msg})
+		fmt.Fprintf(l.w, "%s\n", b)
+		return
+	}
+	if c, ok := levelColors[level]; ok {
+		c.Fprintln(l.w, msg)
+		return
+	}
+	fmt.Fprintln(l.w, msg)
+}
+
+func (l *writer) Debugf(format string, args ...interface{}) { l.log(LevelDebug, format, args...) }
+func (l *writer) Infof(format string, args ...interface{})  { l.log(LevelInfo, format, args...) }
+func (l *writer) Warnf(format string, args ...interface{})  { l.log(LevelWarn, format, args...) }
+func (l *writer) Errorf(format string, args ...interface{}) { l.log(LevelError, format, args...) }
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, LevelWarn, "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Debugf("debug")
+	l.Infof("info")
+	l.Warnf("warn %d", 1)
+	l.Errorf("error")
+	if got := buf.String(); got != "warn 1\nerror\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := New(&buf, LevelDebug, "json")
+	l.Infof("hello %s", "world")
+	if got := buf.String(); !strings.Contains(got, `"level":"info","msg":"hello world"`) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTransportRedactsAuthorization(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nexus", "ok")
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	l, _ := New(&buf, LevelDebug, "text")
+	client := &http.Client{Transport: &Transport{Logger: l}}
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.SetBasicAuth("admin", "admin123")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	got := buf.String()
+	if strings.Contains(got, "YWRtaW46YWRtaW4xMjM=") || !strings.Contains(got, "Authorization: REDACTED") {
+		t.Errorf("Authorization header not redacted:\n%s", got)
+	}
+	if !strings.Contains(got, "X-Nexus: ok") {
+		t.Errorf("response headers missing:\n%s", got)
+	}
+}
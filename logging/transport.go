@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// redactedHeaders are never written by Transport
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Transport is an http.RoundTripper dumping the request and response headers to a logger at debug level.
+// Credentials headers are redacted.
+type Transport struct {
+	// Base is the transport executing the requests, http.DefaultTransport when nil
+	Base   http.RoundTripper
+	Logger Logger
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t.Logger.Debugf("> %s %s %s\n%s", req.Method, req.URL.String(), req.Proto, dumpHeader(req.Header))
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.Logger.Debugf("< %s %s: %v", req.Method, req.URL.String(), err)
+		return nil, err
+	}
+	t.Logger.Debugf("< %s %s\n%s", resp.Proto, resp.Status, dumpHeader(resp.Header))
+	return resp, nil
+}
+
+func dumpHeader(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		for _, value := range h[name] {
+			for _, redacted := range redactedHeaders {
+				if strings.EqualFold(name, redacted) {
+					value = "REDACTED"
+				}
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", name, value)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// parseGradleLockfile lists the dependencies of a gradle.lockfile.
+// Each line is written as G:A:V=configuration,configuration. Legacy per-configuration
+// lockfiles (gradle/dependency-locks/<configuration>.lockfile) have no '=' part and take
+// the configuration from the file name.
+func parseGradleLockfile(path string, b []byte) (*Manifest, Errors) {
+	m := new(Manifest)
+	var errs Errors
+	legacyConfiguration := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if legacyConfiguration == "gradle" {
+		legacyConfiguration = ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "empty=") {
+			continue
+		}
+		coordinates, configurations := text, legacyConfiguration
+		if i := strings.Index(text, "="); i >= 0 {
+			coordinates, configurations = text[:i], text[i+1:]
+		}
+		parts := strings.Split(coordinates, ":")
+		if len(parts) != 3 {
+			errs = append(errs, &Error{Line: line, Msg: fmt.Sprintf("%q is not in group:artifact:version=configurations format", text)})
+			continue
+		}
+		entry := Entry{Line: line, Group: parts[0], Artifact: parts[1], Version: parts[2], Packaging: "jar"}
+		if configurations != "" {
+			entry.Configurations = strings.Split(configurations, ",")
+		}
+		errs = append(errs, entry.validate()...)
+		m.Artifacts = append(m.Artifacts, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, &Error{Msg: err.Error()})
+	}
+	return m, errs
+}
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// decodeJSON decodes a JSON document into a yaml.Node tree so that JSON and YAML manifests
+// share the same validation and both report line numbers.
+func decodeJSON(b []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	node, err := jsonValue(dec, b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &Error{Line: lineAt(b, dec.InputOffset()), Msg: "unexpected data after the top-level value"}
+	}
+	return node, nil
+}
+
+func jsonValue(dec *json.Decoder, b []byte) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(err, dec, b)
+	}
+	line := lineAt(b, dec.InputOffset())
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Line: line}
+		if t == '{' {
+			node.Kind = yaml.MappingNode
+		}
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, jsonError(err, dec, b)
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string), Line: lineAt(b, dec.InputOffset())})
+			}
+			value, err := jsonValue(dec, b)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, jsonError(err, dec, b)
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t, Line: line}, nil
+	case json.Number:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: t.String(), Line: line}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t), Line: line}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: line}, nil
+	}
+}
+
+func jsonError(err error, dec *json.Decoder, b []byte) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return &Error{Line: lineAt(b, e.Offset), Msg: e.Error()}
+	default:
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return &Error{Line: lineAt(b, int64(len(b))), Msg: "unexpected end of JSON input"}
+		}
+		return &Error{Line: lineAt(b, dec.InputOffset()), Msg: err.Error()}
+	}
+}
+
+// lineAt returns the line number of the byte offset
+func lineAt(b []byte, offset int64) int {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// Lock pins every artifact of a manifest to the exact file that Nexus resolved
+type Lock struct {
+	Artifacts []LockedArtifact `json:"artifacts" yaml:"artifacts"`
+}
+
+// LockedArtifact is the resolution of a single manifest entry
+type LockedArtifact struct {
+	// Coordinates are the requested G:A:V:P[:C] coordinates of the manifest entry
+	Coordinates         string `json:"coordinates" yaml:"coordinates"`
+	Repository          string `json:"repository" yaml:"repository"`
+	Version             string `json:"version" yaml:"version"`
+	Snapshot            bool   `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	SnapshotTimeStamp   int64  `json:"snapshotTimeStamp,omitempty" yaml:"snapshotTimeStamp,omitempty"`
+	SnapshotBuildNumber int    `json:"snapshotBuildNumber,omitempty" yaml:"snapshotBuildNumber,omitempty"`
+	RepositoryPath      string `json:"repositoryPath" yaml:"repositoryPath"`
+	Sha1                string `json:"sha1"`
+}
+
+// LockPath returns the default lock file path of a manifest: the manifest path with a .lock extension
+func LockPath(manifestPath string) string {
+	return strings.TrimSuffix(manifestPath, filepath.Ext(manifestPath)) + ".lock"
+}
+
+// ReadLock reads a lock file
+func ReadLock(path string) (*Lock, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := new(Lock)
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return l, nil
+}
+
+// Write writes the lock file
+func (l *Lock) Write(path string) error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Find returns the pinned resolution of an entry
+func (l *Lock) Find(e Entry) (LockedArtifact, bool) {
+	for _, a := range l.Artifacts {
+		if a.Coordinates == e.String() && (e.Repository == "" || e.Repository == a.Repository) {
+			return a, true
+		}
+	}
+	return LockedArtifact{}, false
+}
+
+// NewLockedArtifact records the resolution of the request made for an entry
+func NewLockedArtifact(e Entry, r nexus.Request, res *nexus.Resolution) LockedArtifact {
+	repository := r.Repository
+	if repository == "" {
+		repository = nexus.DefaultRepository(r.Version)
+	}
+	a := LockedArtifact{
+		Coordinates:         e.String(),
+		Repository:          repository,
+		Version:             res.Version,
+		Snapshot:            res.Snapshot,
+		SnapshotBuildNumber: res.BuildNumber,
+		RepositoryPath:      "/" + res.Path,
+		Sha1:                res.Sha1,
+	}
+	// the timestamp is in milliseconds, as reported by the resolve endpoint of Nexus 2
+	if !res.Timestamp.IsZero() {
+		a.SnapshotTimeStamp = res.Timestamp.UnixNano() / int64(time.Millisecond)
+	}
+	return a
+}
+
+// Pin returns the request downloading exactly the locked version from the locked repository.
+// The download fails when Nexus no longer resolves the locked sha1.
+func (a LockedArtifact) Pin(r nexus.Request) (nexus.Request, error) {
+	if r.Sha1 != "" && !strings.EqualFold(r.Sha1, a.Sha1) {
+		return r, fmt.Errorf("manifest sha1 %s differs from the locked sha1 %s", r.Sha1, a.Sha1)
+	}
+	r.Repository = a.Repository
+	r.Version = a.Version
+	r.Sha1 = a.Sha1
+	return r, nil
+}
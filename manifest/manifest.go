@@ -0,0 +1,315 @@
+// Package manifest loads the artifact lists downloaded by the multi-download command.
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bzon/nexus-cli/nexus"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Server holds the Nexus connection settings of a manifest
+type Server struct {
+	Host, Username, Password string
+}
+
+// Entry is a single artifact of a manifest
+type Entry struct {
+	// Line is the line of the manifest where the entry starts
+	Line int
+
+	Group, Artifact, Version, Packaging, Classifier, Extension string
+	Repository, Destination, Rename, Sha1                      string
+
+	// Scope is the Maven scope of an entry imported from a pom.xml
+	Scope string
+	// Configurations are the Gradle configurations of an entry imported from a gradle.lockfile
+	Configurations []string
+}
+
+// Manifest is a list of artifacts to download with the defaults and the server they are downloaded from
+type Manifest struct {
+	Server    Server
+	Defaults  Entry
+	Artifacts []Entry
+}
+
+// Error is a manifest error pointing at a line of the manifest file
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Errors holds every validation error found in a manifest
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var (
+	serverFields   = []string{"host", "username", "password"}
+	defaultsFields = []string{"repository", "version", "packaging", "classifier", "extension", "destination"}
+	entryFields    = []string{"group", "artifact", "version", "packaging", "classifier", "extension", "repository", "destination", "rename", "sha1"}
+)
+
+// Load reads a manifest file. The format is chosen by the file extension: .txt, .yaml, .yml or .json,
+// a Maven pom.xml or a Gradle .lockfile.
+func Load(path string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root *yaml.Node
+	var m *Manifest
+	var errs Errors
+	switch ext := strings.ToLower(filepath.Ext(path)); {
+	case ext == ".txt":
+		m, errs = parseText(b)
+	case ext == ".pom" || ext == ".xml" && isPOM(b):
+		m, errs = parsePOM(b)
+	case ext == ".lockfile":
+		m, errs = parseGradleLockfile(path, b)
+	case ext == ".yaml" || ext == ".yml":
+		root, err = decodeYAML(b)
+	case ext == ".json":
+		root, err = decodeJSON(b)
+	default:
+		return nil, fmt.Errorf("%s: unsupported manifest format, expected a .txt, .yaml, .yml, .json, pom.xml or gradle.lockfile file", path)
+	}
+	if err != nil {
+		if e, ok := err.(*Error); ok {
+			e.File = path
+		}
+		return nil, err
+	}
+	if root != nil {
+		m, errs = parse(root)
+	}
+	if len(errs) > 0 {
+		for _, e := range errs {
+			e.File = path
+		}
+		return nil, errs
+	}
+	return m, nil
+}
+
+// Filter keeps the entries in one of the Maven scopes and one of the Gradle configurations.
+// An empty list does not filter and entries without a scope or configuration are always kept.
+func Filter(entries []Entry, scopes, configurations []string) []Entry {
+	var kept []Entry
+	for _, e := range entries {
+		if len(scopes) > 0 && e.Scope != "" && !contains(scopes, e.Scope) {
+			continue
+		}
+		if len(configurations) > 0 && len(e.Configurations) > 0 && !containsAny(configurations, e.Configurations) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// Request returns the Request downloading the entry. The extension defaults to the packaging.
+// The repository and the destination directory that are empty are taken from base.
+func (e Entry) Request(base nexus.Request) nexus.Request {
+	r := base
+	r.Group = e.Group
+	r.Artifact = e.Artifact
+	r.Version = e.Version
+	r.Classifier = e.Classifier
+	r.Extension = e.Extension
+	if r.Extension == "" {
+		r.Extension = e.Packaging
+	}
+	r.Filename = e.Rename
+	r.Sha1 = e.Sha1
+	if e.Repository != "" {
+		r.Repository = e.Repository
+	}
+	if e.Destination != "" {
+		r.Dir = e.Destination
+	}
+	return r
+}
+
+// String returns the entry in G:A:V:P[:C] format
+func (e Entry) String() string {
+	s := e.Group + ":" + e.Artifact + ":" + e.Version + ":" + e.Packaging
+	if e.Classifier != "" {
+		s += ":" + e.Classifier
+	}
+	return s
+}
+
+func decodeYAML(b []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, yamlError(err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, &Error{Line: 1, Msg: "empty manifest"}
+	}
+	return doc.Content[0], nil
+}
+
+// yamlError turns a "yaml: line N: msg" error into an Error
+func yamlError(err error) error {
+	var line int
+	var msg string
+	if n, _ := fmt.Sscanf(err.Error(), "yaml: line %d:", &line); n == 1 {
+		msg = strings.TrimSpace(err.Error()[strings.Index(err.Error(), ":")+1:])
+		msg = strings.TrimSpace(msg[strings.Index(msg, ":")+1:])
+		return &Error{Line: line, Msg: msg}
+	}
+	return err
+}
+
+func parse(root *yaml.Node) (*Manifest, Errors) {
+	var errs Errors
+	if root.Kind != yaml.MappingNode {
+		return nil, append(errs, &Error{Line: root.Line, Msg: "manifest must be a mapping with an 'artifacts' list"})
+	}
+	m := new(Manifest)
+	var artifacts *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "server":
+			f, e := fields(value, "server", serverFields)
+			errs = append(errs, e...)
+			m.Server = Server{Host: f["host"], Username: f["username"], Password: f["password"]}
+		case "defaults":
+			f, e := fields(value, "defaults", defaultsFields)
+			errs = append(errs, e...)
+			m.Defaults = newEntry(value.Line, f)
+		case "artifacts":
+			artifacts = value
+		default:
+			errs = append(errs, &Error{Line: key.Line, Msg: fmt.Sprintf("unknown field %q, expected one of server, defaults, artifacts", key.Value)})
+		}
+	}
+	if artifacts == nil {
+		return nil, append(errs, &Error{Line: root.Line, Msg: "missing required field \"artifacts\""})
+	}
+	if artifacts.Kind != yaml.SequenceNode {
+		return nil, append(errs, &Error{Line: artifacts.Line, Msg: "\"artifacts\" must be a list"})
+	}
+	for _, node := range artifacts.Content {
+		f, e := fields(node, "artifact entry", entryFields)
+		errs = append(errs, e...)
+		entry := newEntry(node.Line, f)
+		entry.applyDefaults(m.Defaults)
+		errs = append(errs, entry.validate()...)
+		m.Artifacts = append(m.Artifacts, entry)
+	}
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return nil, errs
+	}
+	return m, nil
+}
+
+// fields reads a mapping of scalar values and reports unknown fields
+func fields(node *yaml.Node, name string, allowed []string) (map[string]string, Errors) {
+	var errs Errors
+	if node.Kind != yaml.MappingNode {
+		return nil, append(errs, &Error{Line: node.Line, Msg: name + " must be a mapping"})
+	}
+	f := make(map[string]string)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !contains(allowed, key.Value) {
+			errs = append(errs, &Error{Line: key.Line, Msg: fmt.Sprintf("unknown field %q in %s, expected one of %s", key.Value, name, strings.Join(allowed, ", "))})
+			continue
+		}
+		if value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+			errs = append(errs, &Error{Line: value.Line, Msg: fmt.Sprintf("field %q must be a string", key.Value)})
+			continue
+		}
+		f[key.Value] = value.Value
+	}
+	return f, errs
+}
+
+func newEntry(line int, f map[string]string) Entry {
+	return Entry{
+		Line:        line,
+		Group:       f["group"],
+		Artifact:    f["artifact"],
+		Version:     f["version"],
+		Packaging:   f["packaging"],
+		Classifier:  f["classifier"],
+		Extension:   f["extension"],
+		Repository:  f["repository"],
+		Destination: f["destination"],
+		Rename:      f["rename"],
+		Sha1:        f["sha1"],
+	}
+}
+
+func (e *Entry) applyDefaults(d Entry) {
+	for _, p := range []struct {
+		field *string
+		value string
+	}{
+		{&e.Repository, d.Repository},
+		{&e.Version, d.Version},
+		{&e.Packaging, d.Packaging},
+		{&e.Classifier, d.Classifier},
+		{&e.Extension, d.Extension},
+		{&e.Destination, d.Destination},
+	} {
+		if *p.field == "" {
+			*p.field = p.value
+		}
+	}
+	if e.Version == "" {
+		e.Version = "LATEST"
+	}
+}
+
+func (e Entry) validate() Errors {
+	var errs Errors
+	for _, f := range []struct{ name, value string }{{"group", e.Group}, {"artifact", e.Artifact}, {"packaging", e.Packaging}} {
+		if f.value == "" {
+			errs = append(errs, &Error{Line: e.Line, Msg: fmt.Sprintf("missing required field %q", f.name)})
+		}
+	}
+	if strings.ContainsAny(e.Rename, `/\`) {
+		errs = append(errs, &Error{Line: e.Line, Msg: "field \"rename\" must be a file name, use \"destination\" for the directory"})
+	}
+	return errs
+}
+
+func containsAny(list []string, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
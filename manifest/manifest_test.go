@@ -0,0 +1,282 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+func writeManifest(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]string{
+		"artifacts.yaml": `server:
+  host: http://localhost:8081/nexus
+defaults:
+  repository: releases
+  packaging: jar
+artifacts:
+  - group: com.example
+    artifact: artifactA
+  - group: com.example
+    artifact: artifactB
+    version: 1.0
+    classifier: sources
+    rename: b-sources.jar
+`,
+		"artifacts.json": `{
+  "server": {"host": "http://localhost:8081/nexus"},
+  "defaults": {"repository": "releases", "packaging": "jar"},
+  "artifacts": [
+    {"group": "com.example", "artifact": "artifactA"},
+    {"group": "com.example", "artifact": "artifactB", "version": 1.0,
+     "classifier": "sources", "rename": "b-sources.jar"}
+  ]
+}`,
+	}
+	for name, content := range tests {
+		path := writeManifest(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+		m, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if m.Server.Host != "http://localhost:8081/nexus" {
+			t.Errorf("%s: Server.Host = %q", name, m.Server.Host)
+		}
+		if len(m.Artifacts) != 2 {
+			t.Fatalf("%s: got %d artifacts, want 2", name, len(m.Artifacts))
+		}
+		a, b := m.Artifacts[0], m.Artifacts[1]
+		if a.Version != "LATEST" || a.Packaging != "jar" || a.Repository != "releases" {
+			t.Errorf("%s: defaults not applied to %+v", name, a)
+		}
+		if b.Version != "1.0" || b.Classifier != "sources" || b.Rename != "b-sources.jar" {
+			t.Errorf("%s: unexpected entry %+v", name, b)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct{ content, want string }{
+		"missing.yaml": {`artifacts:
+  - group: com.example
+    packaging: jar
+`, "missing.yaml:2: missing required field \"artifact\""},
+		"unknown.yaml": {`artifacts:
+  - group: com.example
+    artifact: a
+    packaging: jar
+    clasifier: sources
+`, "unknown.yaml:5: unknown field \"clasifier\""},
+		"syntax.json": {`{
+  "artifacts": [
+    {"group": "com.example",}
+  ]
+}`, "syntax.json:3: invalid character"},
+		"nested.json": {`{
+  "artifacts": [
+    {"group": "com.example", "artifact": "a", "packaging": "jar"},
+    {"group": "com.example", "artifact": ["a"], "packaging": "jar"}
+  ]
+}`, "nested.json:4: field \"artifact\" must be a string"},
+	}
+	for name, tt := range tests {
+		path := writeManifest(t, name, tt.content)
+		defer os.RemoveAll(filepath.Dir(path))
+		_, err := Load(path)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %q, want it to contain %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestLoadText(t *testing.T) {
+	path := writeManifest(t, "artifacts.txt", `# release artifacts
+com.example:artifactA:1.0.1:jar
+
+com.example:artifactB:1.0-SNAPSHOT:war
+com.example:artifactC:LATEST:jar:sources
+
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Artifacts) != 3 {
+		t.Fatalf("got %d artifacts, want 3", len(m.Artifacts))
+	}
+	if c := m.Artifacts[2]; c.Line != 5 || c.Classifier != "sources" {
+		t.Errorf("unexpected entry %+v", c)
+	}
+
+	path = writeManifest(t, "broken.txt", "com.example:artifactA:1.0.1:jar\ncom.example:artifactB\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "broken.txt:2:") {
+		t.Errorf("got %v, want an error on line 2", err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	entry := Entry{Group: "com.example", Artifact: "artifactB", Version: "1.0-SNAPSHOT", Packaging: "war"}
+	r := nexus.Resolution{Snapshot: true, BuildNumber: 3, Sha1: "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"}
+	r.Version = "1.0-20180101.120000-3"
+	r.Timestamp = time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+	lock := Lock{Artifacts: []LockedArtifact{NewLockedArtifact(entry, entry.Request(nexus.Request{}), &r)}}
+	path := LockPath(filepath.Join(dir, "artifacts.yaml"))
+	if err := lock.Write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, found := read.Find(entry)
+	if !found {
+		t.Fatalf("%s not found in %+v", entry, read)
+	}
+	if locked.Repository != "snapshots" || locked.SnapshotTimeStamp != 1514808000000 {
+		t.Errorf("locked = %+v, want snapshots and the timestamp in milliseconds", locked)
+	}
+	pinned, err := locked.Pin(entry.Request(nexus.Request{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned.Version != r.Version || pinned.Sha1 != r.Sha1 || pinned.Extension != "war" {
+		t.Errorf("Pin() = %+v, want version %s, sha1 %s and extension war", pinned, r.Version, r.Sha1)
+	}
+
+	entry.Sha1 = "0000000000000000000000000000000000000000"
+	if _, err := locked.Pin(entry.Request(nexus.Request{})); err == nil {
+		t.Error("Pin() should fail when the manifest sha1 differs from the lock")
+	}
+}
+
+func TestLoadPOM(t *testing.T) {
+	path := writeManifest(t, "pom.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <properties>
+    <guava.version>28.0-jre</guava.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>bom</artifactId>
+        <version>${project.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.12</version>
+      <scope>test</scope>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>core</artifactId>
+      <version>2.0.0</version>
+      <type>test-jar</type>
+      <scope>test</scope>
+    </dependency>
+    <dependency>
+      <groupId>org.example</groupId>
+      <artifactId>osgi</artifactId>
+      <version>1.0</version>
+      <type>bundle</type>
+    </dependency>
+  </dependencies>
+</project>
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range m.Artifacts {
+		got = append(got, e.String()+"@"+e.Scope)
+	}
+	want := "com.google.guava:guava:28.0-jre:jar@compile junit:junit:4.12:jar@test com.example:core:2.0.0:test-jar:tests@test org.example:osgi:1.0:bundle@compile com.example:bom:2.0.0:pom@import"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+	// Types are mapped to the file of their artifact handler
+	for i, want := range map[int]string{2: "jar:tests", 3: "jar:", 4: "pom:"} {
+		if r := m.Artifacts[i].Request(nexus.Request{}); r.Extension+":"+r.Classifier != want {
+			t.Errorf("%s: got extension and classifier %s:%s, want %s", m.Artifacts[i], r.Extension, r.Classifier, want)
+		}
+	}
+	if m.Artifacts[1].Line != 33 {
+		t.Errorf("junit Line = %d, want 33", m.Artifacts[1].Line)
+	}
+	if kept := Filter(m.Artifacts, []string{"compile", "runtime"}, nil); len(kept) != 2 {
+		t.Errorf("Filter() kept %v, want only guava and osgi", kept)
+	}
+}
+
+func TestLoadGradleLockfile(t *testing.T) {
+	path := writeManifest(t, "gradle.lockfile", `# This is a Gradle generated file for dependency locking.
+com.google.guava:guava:28.0-jre=compileClasspath,runtimeClasspath
+junit:junit:4.12=testCompileClasspath,testRuntimeClasspath
+empty=annotationProcessor
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Artifacts) != 2 {
+		t.Fatalf("got %d artifacts, want 2", len(m.Artifacts))
+	}
+	kept := Filter(m.Artifacts, nil, []string{"runtimeClasspath"})
+	if len(kept) != 1 || kept[0].Artifact != "guava" || kept[0].Version != "28.0-jre" {
+		t.Errorf("Filter() kept %v, want only guava", kept)
+	}
+}
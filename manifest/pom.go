@@ -0,0 +1,202 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pom holds the parts of a Maven pom.xml needed to list its dependencies
+type pom struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	} `xml:"parent"`
+	Properties           pomProperties   `xml:"properties"`
+	Dependencies         []pomDependency `xml:"dependencies>dependency"`
+	DependencyManagement []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+}
+
+type pomDependency struct {
+	Line       int
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Classifier string `xml:"classifier"`
+	Scope      string `xml:"scope"`
+}
+
+// UnmarshalXML records the line where the dependency starts
+func (d *pomDependency) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	line, _ := dec.InputPos()
+	type plain pomDependency
+	if err := dec.DecodeElement((*plain)(d), &start); err != nil {
+		return err
+	}
+	d.Line = line
+	return nil
+}
+
+// pomProperties holds the free-form <properties> of a pom
+type pomProperties map[string]string
+
+// UnmarshalXML reads every child element of <properties> as a property
+func (p *pomProperties) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	*p = make(pomProperties)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var value string
+			if err := dec.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+var propertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePOM lists the dependencies and managed dependencies of a pom.xml.
+// Dependencies without a version take it from dependencyManagement and ${...} properties are interpolated.
+func parsePOM(b []byte) (*Manifest, Errors) {
+	var p pom
+	if err := xml.Unmarshal(b, &p); err != nil {
+		line := 1
+		if e, ok := err.(*xml.SyntaxError); ok {
+			line = e.Line
+		}
+		return nil, Errors{&Error{Line: line, Msg: err.Error()}}
+	}
+	props := map[string]string{}
+	for k, v := range p.Properties {
+		props[k] = v
+	}
+	groupID, version := p.GroupID, p.Version
+	if groupID == "" {
+		groupID = p.Parent.GroupID
+	}
+	if version == "" {
+		version = p.Parent.Version
+	}
+	for _, prefix := range []string{"project.", "pom.", ""} {
+		props[prefix+"groupId"] = groupID
+		props[prefix+"artifactId"] = p.ArtifactID
+		props[prefix+"version"] = version
+	}
+	props["project.parent.groupId"] = p.Parent.GroupID
+	props["project.parent.version"] = p.Parent.Version
+
+	var errs Errors
+	interpolate := func(line int, s string) string {
+		for depth := 0; strings.Contains(s, "${"); depth++ {
+			if depth == 10 {
+				errs = append(errs, &Error{Line: line, Msg: fmt.Sprintf("recursive property in %q", s)})
+				return s
+			}
+			s = propertyPattern.ReplaceAllStringFunc(s, func(m string) string {
+				name := m[2 : len(m)-1]
+				if v, ok := props[name]; ok {
+					return v
+				}
+				errs = append(errs, &Error{Line: line, Msg: fmt.Sprintf("undefined property %q", name)})
+				return ""
+			})
+		}
+		return s
+	}
+	toEntry := func(d pomDependency) Entry {
+		e := Entry{
+			Line:       d.Line,
+			Group:      interpolate(d.Line, d.GroupID),
+			Artifact:   interpolate(d.Line, d.ArtifactID),
+			Version:    interpolate(d.Line, d.Version),
+			Packaging:  interpolate(d.Line, d.Type),
+			Classifier: interpolate(d.Line, d.Classifier),
+			Scope:      interpolate(d.Line, d.Scope),
+		}
+		if e.Packaging == "" {
+			e.Packaging = "jar"
+		}
+		if h, found := artifactHandlers[e.Packaging]; found {
+			e.Extension = h.extension
+			if e.Classifier == "" {
+				e.Classifier = h.classifier
+			}
+		}
+		if e.Scope == "" {
+			e.Scope = "compile"
+		}
+		return e
+	}
+	key := func(e Entry) string { return e.Group + ":" + e.Artifact + ":" + e.Packaging + ":" + e.Classifier }
+
+	managed := map[string]Entry{}
+	var managedOrder []string
+	for _, d := range p.DependencyManagement {
+		e := toEntry(d)
+		if _, found := managed[key(e)]; !found {
+			managedOrder = append(managedOrder, key(e))
+		}
+		managed[key(e)] = e
+	}
+
+	m := new(Manifest)
+	seen := map[string]bool{}
+	for _, d := range p.Dependencies {
+		e := toEntry(d)
+		if mgmt, found := managed[key(e)]; found {
+			if e.Version == "" {
+				e.Version = mgmt.Version
+			}
+			if d.Scope == "" && mgmt.Scope != "" {
+				e.Scope = mgmt.Scope
+			}
+		}
+		seen[key(e)] = true
+		m.Artifacts = append(m.Artifacts, e)
+	}
+	for _, k := range managedOrder {
+		if !seen[k] {
+			m.Artifacts = append(m.Artifacts, managed[k])
+		}
+	}
+	for i := range m.Artifacts {
+		e := &m.Artifacts[i]
+		if e.Version == "" {
+			errs = append(errs, &Error{Line: e.Line, Msg: fmt.Sprintf("%s:%s has no version and is not managed", e.Group, e.Artifact)})
+		}
+		errs = append(errs, e.validate()...)
+	}
+	return m, errs
+}
+
+// artifactHandlers are the standard Maven artifact handlers of the dependency types whose file
+// is not named after the type, for example a test-jar is the jar with the tests classifier
+var artifactHandlers = map[string]struct{ extension, classifier string }{
+	"test-jar":     {"jar", "tests"},
+	"ejb-client":   {"jar", "client"},
+	"java-source":  {"jar", "sources"},
+	"javadoc":      {"jar", "javadoc"},
+	"maven-plugin": {"jar", ""},
+	"ejb":          {"jar", ""},
+	"bundle":       {"jar", ""},
+}
+
+// isPOM tells whether the file content looks like a Maven project
+func isPOM(b []byte) bool {
+	return bytes.Contains(b, []byte("<project"))
+}
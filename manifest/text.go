@@ -0,0 +1,41 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseText reads a text manifest holding one G:A:V:P[:C] coordinate per line.
+// Blank lines and lines starting with # are ignored.
+func parseText(b []byte) (*Manifest, Errors) {
+	m := new(Manifest)
+	var errs Errors
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, ":")
+		if len(parts) < 4 || len(parts) > 5 {
+			errs = append(errs, &Error{Line: line, Msg: fmt.Sprintf("%q is not in G:A:V:P[:C] format", text)})
+			continue
+		}
+		entry := Entry{Line: line, Group: parts[0], Artifact: parts[1], Version: parts[2], Packaging: parts[3]}
+		if len(parts) == 5 {
+			entry.Classifier = parts[4]
+		}
+		entry.applyDefaults(Entry{})
+		if e := entry.validate(); len(e) > 0 {
+			errs = append(errs, e...)
+			continue
+		}
+		m.Artifacts = append(m.Artifacts, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, &Error{Msg: err.Error()})
+	}
+	return m, errs
+}
@@ -0,0 +1,85 @@
+package maven
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// masterPasswordKey is the password that encrypts the master password of settings-security.xml
+const masterPasswordKey = "settings.security"
+
+// IsEncrypted reports whether a settings.xml password is encrypted, that is written in {braces}
+func IsEncrypted(s string) bool {
+	_, ok := encryptedPart(s)
+	return ok
+}
+
+// encryptedPart returns the text between the braces of an encrypted password.
+// Like Maven, text around the braces is ignored and escaped \{ \} braces are not braces.
+func encryptedPart(s string) (string, bool) {
+	start := -1
+	for i := 0; i < len(s); i++ {
+		if s[i] == '{' && (i == 0 || s[i-1] != '\\') {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	for i := len(s) - 1; i > start; i-- {
+		if s[i] == '}' && s[i-1] != '\\' {
+			return s[start+1 : i], true
+		}
+	}
+	return "", false
+}
+
+// Decrypt decrypts a {...} password the way the Maven (plexus) cipher does:
+// the base64 payload holds an 8 byte salt, the padding length and the AES-128-CBC cipher text,
+// and the key and iv are the SHA-256 digest of the password followed by the salt.
+func Decrypt(encrypted, password string) (string, error) {
+	payload, ok := encryptedPart(encrypted)
+	if !ok {
+		return "", errors.New("the password is not encrypted")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(payload))
+	if err != nil {
+		return "", err
+	}
+	if len(b) < 9 {
+		return "", errors.New("the encrypted password is too short")
+	}
+	salt, padLength := b[:8], int(b[8])
+	if len(b)-9-padLength < 0 {
+		return "", errors.New("the encrypted password is corrupt")
+	}
+	data := b[9 : len(b)-padLength]
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", errors.New("the encrypted password is corrupt")
+	}
+
+	digest := sha256.Sum256(append([]byte(password), salt...))
+	block, err := aes.NewCipher(digest[:16])
+	if err != nil {
+		return "", err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, digest[16:]).CryptBlocks(plain, data)
+
+	// Remove the PKCS5 padding
+	n := int(plain[len(plain)-1])
+	if n < 1 || n > aes.BlockSize || n > len(plain) {
+		return "", errors.New("wrong password")
+	}
+	for _, p := range plain[len(plain)-n:] {
+		if int(p) != n {
+			return "", errors.New("wrong password")
+		}
+	}
+	return string(plain[:len(plain)-n]), nil
+}
@@ -0,0 +1,331 @@
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// LocalRepository is a Maven local repository such as ~/.m2/repository
+type LocalRepository struct {
+	Dir string
+}
+
+// Artifact is the resolved artifact placed in a LocalRepository
+type Artifact struct {
+	GroupID, ArtifactID string
+	// Version is the resolved version, timestamped for snapshots
+	Version string
+	// BaseVersion is the version of the version directory, ending with -SNAPSHOT for snapshots
+	BaseVersion           string
+	Classifier, Extension string
+}
+
+var timestampedVersion = regexp.MustCompile(`^(.*)-(\d{8}\.\d{6})-(\d+)$`)
+
+// SnapshotBuild returns the deploy time and the build number of a timestamped snapshot version
+// such as 1.0-20180101.120000-3, and false for any other version
+func SnapshotBuild(version string) (time.Time, int, bool) {
+	m := timestampedVersion.FindStringSubmatch(version)
+	if m == nil {
+		return time.Time{}, 0, false
+	}
+	timestamp, err := time.Parse("20060102.150405", m[2])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	build, err := strconv.Atoi(m[3])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return timestamp, build, true
+}
+
+// BaseVersion returns the -SNAPSHOT version of a timestamped snapshot version and the version itself otherwise
+func BaseVersion(version string) string {
+	if m := timestampedVersion.FindStringSubmatch(version); m != nil {
+		return m[1] + "-SNAPSHOT"
+	}
+	return version
+}
+
+// CompareVersions compares dotted versions segment by segment, numerically where both segments are numbers.
+// It returns a negative number when a < b, zero when equal and a positive number when a > b.
+func CompareVersions(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	}
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	// a qualifier such as 1.0-rc1 sorts before the plain 1.0 release
+	switch {
+	case len(as) > len(bs):
+		if _, err := strconv.Atoi(as[len(bs)]); err != nil {
+			return -1
+		}
+		return 1
+	case len(as) < len(bs):
+		if _, err := strconv.Atoi(bs[len(as)]); err != nil {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+// Generated reports whether a repository manager creates a file of a remote repository by itself:
+// the maven-metadata.xml files and the checksums of the other files
+func Generated(name string) bool {
+	if strings.HasPrefix(name, "maven-metadata.xml") {
+		return true
+	}
+	for _, ext := range []string{".sha1", ".md5", ".sha256", ".sha512"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot reports whether the artifact is a snapshot
+func (a Artifact) Snapshot() bool {
+	return strings.HasSuffix(a.baseVersion(), "-SNAPSHOT")
+}
+
+func (a Artifact) baseVersion() string {
+	if a.BaseVersion != "" {
+		return a.BaseVersion
+	}
+	return BaseVersion(a.Version)
+}
+
+// DefaultLocalRepositoryPath returns $HOME/.m2/repository
+func DefaultLocalRepositoryPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".m2", "repository"), nil
+}
+
+// ArtifactDir returns <group path>/<artifact> inside the repository
+func (r LocalRepository) ArtifactDir(a Artifact) string {
+	return filepath.Join(r.Dir, filepath.FromSlash(strings.Replace(a.GroupID, ".", "/", -1)), a.ArtifactID)
+}
+
+// VersionDir returns <group path>/<artifact>/<base version> inside the repository
+func (r LocalRepository) VersionDir(a Artifact) string {
+	return filepath.Join(r.ArtifactDir(a), a.baseVersion())
+}
+
+// FileName returns <artifact>-<base version>[-<classifier>].<extension>.
+// Snapshots are named with their -SNAPSHOT version like Maven does for local copies.
+func (r LocalRepository) FileName(a Artifact) string {
+	name := a.ArtifactID + "-" + a.baseVersion()
+	if a.Classifier != "" {
+		name += "-" + a.Classifier
+	}
+	return name + "." + a.Extension
+}
+
+// Install writes the bookkeeping of an artifact file already placed at VersionDir/FileName:
+// the .sha1 companion, the _remote.repositories entry and the maven-metadata-local.xml files.
+// The _remote.repositories entry has no repository id, like artifacts installed by
+// 'mvn install', so that any build finds it offline.
+// Installs of the same artifact are serialized, as they read and rewrite the same files.
+func (r LocalRepository) Install(a Artifact, sha1 string) error {
+	unlock := lockDir(r.ArtifactDir(a))
+	defer unlock()
+	dir := r.VersionDir(a)
+	fileName := r.FileName(a)
+	if err := writeFile(filepath.Join(dir, fileName+".sha1"), []byte(sha1)); err != nil {
+		return err
+	}
+	if err := addRemoteRepository(filepath.Join(dir, "_remote.repositories"), fileName); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if err := updateMetadata(filepath.Join(r.ArtifactDir(a), "maven-metadata-local.xml"), a, now, func(m *metadata) {
+		if m.Versioning.Versions == nil {
+			m.Versioning.Versions = &versions{}
+		}
+		m.Versioning.Versions.Version = addVersion(m.Versioning.Versions.Version, a.baseVersion())
+		if !a.Snapshot() {
+			m.Versioning.Release = a.baseVersion()
+		}
+	}); err != nil {
+		return err
+	}
+	if !a.Snapshot() {
+		return nil
+	}
+	return updateMetadata(filepath.Join(dir, "maven-metadata-local.xml"), a, now, func(m *metadata) {
+		m.Version = a.baseVersion()
+		m.Versioning.Snapshot = &snapshot{LocalCopy: true}
+		if m.Versioning.SnapshotVersions == nil {
+			m.Versioning.SnapshotVersions = &snapshotVersions{}
+		}
+		m.Versioning.SnapshotVersions.SnapshotVersion = addSnapshotVersion(m.Versioning.SnapshotVersions.SnapshotVersion, snapshotVersion{
+			Classifier: a.Classifier,
+			Extension:  a.Extension,
+			Value:      a.baseVersion(),
+			Updated:    now.Format("20060102150405"),
+		})
+	})
+}
+
+var (
+	dirLocksMu sync.Mutex
+	dirLocks   = map[string]*sync.Mutex{}
+)
+
+// lockDir locks a directory against the other installs of the process and returns the unlock function
+func lockDir(dir string) func() {
+	dirLocksMu.Lock()
+	mu, found := dirLocks[dir]
+	if !found {
+		mu = &sync.Mutex{}
+		dirLocks[dir] = mu
+	}
+	dirLocksMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// writeFile writes a file through a temporary file renamed over it, so that readers never see a partial file
+func writeFile(path string, content []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// addRemoteRepository adds a file to a _remote.repositories file
+func addRemoteRepository(path, fileName string) error {
+	lines := []string{}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, fileName+">") {
+				lines = append(lines, line)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	lines = append(lines, fileName+">=")
+	sort.Strings(lines)
+	content := "#NOTE: This is a Maven Resolver internal implementation file, its format can be changed without prior notice.\n" +
+		"#" + time.Now().Format(time.UnixDate) + "\n" + strings.Join(lines, "\n") + "\n"
+	return writeFile(path, []byte(content))
+}
+
+// metadata is a maven-metadata-local.xml file
+type metadata struct {
+	XMLName    xml.Name   `xml:"metadata"`
+	GroupID    string     `xml:"groupId"`
+	ArtifactID string     `xml:"artifactId"`
+	Version    string     `xml:"version,omitempty"`
+	Versioning versioning `xml:"versioning"`
+}
+
+type versioning struct {
+	Release          string            `xml:"release,omitempty"`
+	Snapshot         *snapshot         `xml:"snapshot,omitempty"`
+	Versions         *versions         `xml:"versions,omitempty"`
+	LastUpdated      string            `xml:"lastUpdated"`
+	SnapshotVersions *snapshotVersions `xml:"snapshotVersions,omitempty"`
+}
+
+type versions struct {
+	Version []string `xml:"version"`
+}
+
+type snapshotVersions struct {
+	SnapshotVersion []snapshotVersion `xml:"snapshotVersion"`
+}
+
+type snapshot struct {
+	LocalCopy bool `xml:"localCopy"`
+}
+
+type snapshotVersion struct {
+	Classifier string `xml:"classifier,omitempty"`
+	Extension  string `xml:"extension"`
+	Value      string `xml:"value"`
+	Updated    string `xml:"updated"`
+}
+
+// updateMetadata reads a maven-metadata-local.xml file, applies update and writes it back
+func updateMetadata(path string, a Artifact, now time.Time, update func(m *metadata)) error {
+	m := &metadata{}
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if err := xml.Unmarshal(b, m); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	m.GroupID, m.ArtifactID = a.GroupID, a.ArtifactID
+	update(m)
+	m.Versioning.LastUpdated = now.Format("20060102150405")
+	b, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append([]byte(xml.Header), append(b, '\n')...))
+}
+
+func addVersion(versions []string, version string) []string {
+	for _, v := range versions {
+		if v == version {
+			return versions
+		}
+	}
+	return append(versions, version)
+}
+
+func addSnapshotVersion(versions []snapshotVersion, sv snapshotVersion) []snapshotVersion {
+	for i, v := range versions {
+		if v.Classifier == sv.Classifier && v.Extension == sv.Extension {
+			versions[i] = sv
+			return versions
+		}
+	}
+	return append(versions, sv)
+}
@@ -0,0 +1,146 @@
+package maven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := LocalRepository{Dir: dir}
+
+	for _, a := range []Artifact{
+		{GroupID: "com.example", ArtifactID: "foo", Version: "1.0", Extension: "jar"},
+		{GroupID: "com.example", ArtifactID: "foo", Version: "1.0", Extension: "pom"},
+		{GroupID: "com.example", ArtifactID: "foo", Version: "1.1-20181012.101010-3", Classifier: "sources", Extension: "jar"},
+	} {
+		if err := os.MkdirAll(r.VersionDir(a), 0755); err != nil {
+			t.Fatal(err)
+		}
+		ioutil.WriteFile(filepath.Join(r.VersionDir(a), r.FileName(a)), []byte("content"), 0644)
+		if err := r.Install(a, "040f06fd774092478d450774f5ba30c5da78acc8"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"com/example/foo/1.0/foo-1.0.jar.sha1":                      "040f06fd774092478d450774f5ba30c5da78acc8",
+		"com/example/foo/1.0/_remote.repositories":                  "foo-1.0.jar>=\nfoo-1.0.pom>=\n",
+		"com/example/foo/maven-metadata-local.xml":                  "<versions>\n      <version>1.0</version>\n      <version>1.1-SNAPSHOT</version>\n    </versions>",
+		"com/example/foo/1.1-SNAPSHOT/foo-1.1-SNAPSHOT-sources.jar": "content",
+		"com/example/foo/1.1-SNAPSHOT/maven-metadata-local.xml":     "<localCopy>true</localCopy>",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), want) {
+			t.Errorf("%s: got\n%s\nwant it to contain\n%s", path, b, want)
+		}
+	}
+}
+
+func TestConcurrentInstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := LocalRepository{Dir: dir}
+
+	var artifacts []Artifact
+	for _, version := range []string{"1.0", "1.1", "1.2", "1.3"} {
+		for _, extension := range []string{"jar", "pom", "war", "zip"} {
+			artifacts = append(artifacts, Artifact{GroupID: "com.example", ArtifactID: "foo", Version: version, Extension: extension})
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(artifacts))
+	for _, a := range artifacts {
+		if err := os.MkdirAll(r.VersionDir(a), 0755); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(a Artifact) {
+			defer wg.Done()
+			errs <- r.Install(a, "040f06fd774092478d450774f5ba30c5da78acc8")
+		}(a)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, _ := ioutil.ReadFile(filepath.Join(r.ArtifactDir(artifacts[0]), "maven-metadata-local.xml"))
+	for _, version := range []string{"1.0", "1.1", "1.2", "1.3"} {
+		if !strings.Contains(string(b), "<version>"+version+"</version>") {
+			t.Errorf("maven-metadata-local.xml lost version %s:\n%s", version, b)
+		}
+		remote, _ := ioutil.ReadFile(filepath.Join(dir, "com/example/foo", version, "_remote.repositories"))
+		if n := strings.Count(string(remote), ">="); n != 4 {
+			t.Errorf("%s/_remote.repositories has %d entries, want 4:\n%s", version, n, remote)
+		}
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	for name, want := range map[string]bool{
+		"maven-metadata.xml":      true,
+		"maven-metadata.xml.sha1": true,
+		"foo-1.0.jar.md5":         true,
+		"foo-1.0.jar.sha512":      true,
+		"foo-1.0.jar":             false,
+		"foo-1.0.jar.asc":         false,
+		"foo-1.0.pom":             false,
+	} {
+		if got := Generated(name); got != want {
+			t.Errorf("Generated(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestBaseVersion(t *testing.T) {
+	for version, want := range map[string]string{
+		"1.0":                   "1.0",
+		"1.0-SNAPSHOT":          "1.0-SNAPSHOT",
+		"1.0-20181012.101010-3": "1.0-SNAPSHOT",
+	} {
+		if got := BaseVersion(version); got != want {
+			t.Errorf("BaseVersion(%s) = %s, want %s", version, got, want)
+		}
+	}
+}
+
+func TestSnapshotBuild(t *testing.T) {
+	timestamp, build, ok := SnapshotBuild("1.0-20181012.101010-3")
+	if !ok || build != 3 || !timestamp.Equal(time.Date(2018, 10, 12, 10, 10, 10, 0, time.UTC)) {
+		t.Errorf("SnapshotBuild() = %v, %d, %v", timestamp, build, ok)
+	}
+	if _, _, ok := SnapshotBuild("1.0-SNAPSHOT"); ok {
+		t.Error("SnapshotBuild(1.0-SNAPSHOT) should not be a build")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	ordered := []string{"0.9", "1.0-rc1", "1.0", "1.0.1", "1.2", "1.10"}
+	for i := 1; i < len(ordered); i++ {
+		if CompareVersions(ordered[i-1], ordered[i]) >= 0 {
+			t.Errorf("CompareVersions(%q, %q) should be negative", ordered[i-1], ordered[i])
+		}
+		if CompareVersions(ordered[i], ordered[i-1]) <= 0 {
+			t.Errorf("CompareVersions(%q, %q) should be positive", ordered[i], ordered[i-1])
+		}
+	}
+}
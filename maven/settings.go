@@ -0,0 +1,188 @@
+// Package maven reads the credentials and mirrors of a Maven settings.xml file.
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// Settings is the part of a Maven settings.xml used by nexus-cli
+type Settings struct {
+	Servers []Server `xml:"servers>server"`
+	Mirrors []Mirror `xml:"mirrors>mirror"`
+
+	// SecurityPath is the settings-security.xml file holding the master password of the encrypted
+	// passwords, like the settings.security system property of Maven. DefaultSecurityPath is used when empty.
+	SecurityPath string `xml:"-"`
+
+	// path is the settings.xml file the settings were read from
+	path string
+}
+
+// Server is a <server> entry of settings.xml
+type Server struct {
+	ID       string `xml:"id"`
+	Username string `xml:"username"`
+	// Password is either plain or encrypted with the master password of settings-security.xml
+	Password string `xml:"password"`
+}
+
+// Mirror is a <mirror> entry of settings.xml
+type Mirror struct {
+	ID       string `xml:"id"`
+	MirrorOf string `xml:"mirrorOf"`
+	URL      string `xml:"url"`
+}
+
+// securitySettings is the content of a settings-security.xml file
+type securitySettings struct {
+	Master     string `xml:"master"`
+	Relocation string `xml:"relocation"`
+}
+
+// DefaultSettingsPath returns $HOME/.m2/settings.xml
+func DefaultSettingsPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".m2", "settings.xml"), nil
+}
+
+// DefaultSecurityPath returns $HOME/.m2/settings-security.xml, where Maven looks for the master
+// password whatever the settings.xml file in use
+func DefaultSecurityPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".m2", "settings-security.xml"), nil
+}
+
+// ReadSettings reads a settings.xml file
+func ReadSettings(path string) (*Settings, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Settings{path: path}
+	if err := xml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i := range s.Servers {
+		s.Servers[i].ID = strings.TrimSpace(s.Servers[i].ID)
+		s.Servers[i].Username = strings.TrimSpace(s.Servers[i].Username)
+		s.Servers[i].Password = strings.TrimSpace(s.Servers[i].Password)
+	}
+	for i := range s.Mirrors {
+		s.Mirrors[i].ID = strings.TrimSpace(s.Mirrors[i].ID)
+		s.Mirrors[i].URL = strings.TrimSpace(s.Mirrors[i].URL)
+	}
+	return s, nil
+}
+
+// Server returns the <server> entry with the given id
+func (s *Settings) Server(id string) (Server, bool) {
+	for _, server := range s.Servers {
+		if server.ID == id {
+			return server, true
+		}
+	}
+	return Server{}, false
+}
+
+// Mirror returns the <mirror> entry with the given id
+func (s *Settings) Mirror(id string) (Mirror, bool) {
+	for _, mirror := range s.Mirrors {
+		if mirror.ID == id {
+			return mirror, true
+		}
+	}
+	return Mirror{}, false
+}
+
+// Credentials returns the username and the decrypted password of the server with the given id.
+// Encrypted passwords are decrypted with the master password of the SecurityPath file.
+func (s *Settings) Credentials(id string) (username, password string, err error) {
+	server, found := s.Server(id)
+	if !found {
+		return "", "", fmt.Errorf("%s: no server with id %q", s.path, id)
+	}
+	if !IsEncrypted(server.Password) {
+		return server.Username, server.Password, nil
+	}
+	securityPath := s.SecurityPath
+	if securityPath == "" {
+		if securityPath, err = DefaultSecurityPath(); err != nil {
+			return "", "", err
+		}
+	}
+	master, err := MasterPassword(securityPath)
+	if err != nil {
+		return "", "", err
+	}
+	password, err = Decrypt(server.Password, master)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: server %q: %v", s.path, id, err)
+	}
+	return server.Username, password, nil
+}
+
+// MasterPassword reads and decrypts the master password of a settings-security.xml file.
+// A <relocation> to another settings-security.xml file is followed once.
+func MasterPassword(path string) (string, error) {
+	security, err := readSecuritySettings(path)
+	if err != nil {
+		return "", err
+	}
+	if security.Master == "" && security.Relocation != "" {
+		if security, err = readSecuritySettings(expandHome(security.Relocation)); err != nil {
+			return "", err
+		}
+	}
+	if security.Master == "" {
+		return "", fmt.Errorf("%s: no master password", path)
+	}
+	master, err := Decrypt(security.Master, masterPasswordKey)
+	if err != nil {
+		return "", fmt.Errorf("%s: master password: %v", path, err)
+	}
+	return master, nil
+}
+
+func readSecuritySettings(path string) (*securitySettings, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	security := new(securitySettings)
+	if err := xml.Unmarshal(b, security); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	security.Master = strings.TrimSpace(security.Master)
+	security.Relocation = strings.TrimSpace(security.Relocation)
+	return security, nil
+}
+
+func expandHome(path string) string {
+	if expanded, err := homedir.Expand(path); err == nil {
+		return expanded
+	}
+	return path
+}
+
+// HostURL returns the Nexus host url of a repository or mirror url by cutting it
+// before the /content/ (Nexus 2) or /repository/ (Nexus 3) path
+func HostURL(url string) string {
+	for _, marker := range []string{"/content/", "/repository/"} {
+		if i := strings.Index(url, marker); i >= 0 {
+			return url[:i]
+		}
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,133 @@
+package maven
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// encrypt is the reverse of Decrypt, like mvn --encrypt-password
+func encrypt(t *testing.T, plain, password string) string {
+	salt := []byte("12345678")
+	digest := sha256.Sum256(append([]byte(password), salt...))
+	block, err := aes.NewCipher(digest[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append([]byte(plain), bytes.Repeat([]byte{byte(n)}, n)...)
+	cipher.NewCBCEncrypter(block, digest[16:]).CryptBlocks(data, data)
+	padding := []byte{0, 0, 0}
+	payload := append(append(append(salt, byte(len(padding))), data...), padding...)
+	return "{" + base64.StdEncoding.EncodeToString(payload) + "}"
+}
+
+func TestCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	master := encrypt(t, "master secret", masterPasswordKey)
+	password := encrypt(t, "deploy secret", "master secret")
+	settings := `<settings>
+  <servers>
+    <server><id>nexus</id><username>deployer</username><password>Rotated in May ` + password + `</password></server>
+    <server><id>plain</id><username>reader</username><password>reader123</password></server>
+  </servers>
+  <mirrors>
+    <mirror><id>nexus</id><mirrorOf>*</mirrorOf><url>https://nexus.example.com/repository/maven-public/</url></mirror>
+  </mirrors>
+</settings>`
+	ioutil.WriteFile(filepath.Join(dir, "settings.xml"), []byte(settings), 0644)
+	// Maven looks for settings-security.xml in ~/.m2, not next to the settings file in use
+	ioutil.WriteFile(filepath.Join(dir, "settings-security.xml"), []byte("<settingsSecurity><master>{wrong}</master></settingsSecurity>"), 0644)
+	home := filepath.Join(dir, "home")
+	os.MkdirAll(filepath.Join(home, ".m2"), 0755)
+	ioutil.WriteFile(filepath.Join(home, ".m2", "settings-security.xml"), []byte("<settingsSecurity><master>"+master+"</master></settingsSecurity>"), 0644)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	s, err := ReadSettings(filepath.Join(dir, "settings.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string][2]string{
+		"nexus": {"deployer", "deploy secret"},
+		"plain": {"reader", "reader123"},
+	} {
+		username, password, err := s.Credentials(id)
+		if err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if username != want[0] || password != want[1] {
+			t.Errorf("%s: got %s/%s, want %s/%s", id, username, password, want[0], want[1])
+		}
+	}
+	if _, _, err := s.Credentials("missing"); err == nil {
+		t.Error("expected an error for an unknown server id")
+	}
+	if mirror, _ := s.Mirror("nexus"); HostURL(mirror.URL) != "https://nexus.example.com" {
+		t.Errorf("got host %s", HostURL(mirror.URL))
+	}
+	if _, err := Decrypt(password, "wrong"); err == nil {
+		t.Error("expected an error for a wrong master password")
+	}
+
+	// SecurityPath replaces ~/.m2/settings-security.xml, like -Dsettings.security
+	s.SecurityPath = filepath.Join(dir, "settings-security.xml")
+	if _, _, err := s.Credentials("nexus"); err == nil {
+		t.Error("expected an error for the master password of SecurityPath")
+	}
+}
+
+// TestDecryptVectors decrypts fixed vectors, so that Decrypt is not only checked against encrypt.
+// The vectors were produced outside of Go with openssl, following the plexus PBECipher used by
+// mvn --encrypt-master-password and mvn --encrypt-password: AES-128-CBC with the key and iv taken
+// from the SHA-256 digest of the password and a fixed salt.
+func TestDecryptVectors(t *testing.T) {
+	master, err := Decrypt("{mjxR4HstT4YHjzMdW1Ed5/PP2S6caomPbaqqqqqqqqo=}", masterPasswordKey)
+	if err != nil || master != "master secret" {
+		t.Fatalf("got master password %q, %v", master, err)
+	}
+	password, err := Decrypt("{4fBMeiudOFYHkv52WjN/c6HK86ZvuIxktKqqqqqqqqo=}", master)
+	if err != nil || password != "deploy secret" {
+		t.Errorf("got password %q, %v", password, err)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	for s, want := range map[string]bool{
+		"{abc=}":           true,
+		"note {abc=} note": true,
+		"plain":            false,
+		`\{abc=\}`:         false,
+	} {
+		if got := IsEncrypted(s); got != want {
+			t.Errorf("IsEncrypted(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestHostURL(t *testing.T) {
+	for url, want := range map[string]string{
+		"http://localhost:8081/nexus/content/groups/public":  "http://localhost:8081/nexus",
+		"https://nexus.example.com/repository/maven-public/": "https://nexus.example.com",
+		"https://nexus.example.com/":                         "https://nexus.example.com",
+	} {
+		if got := HostURL(url); got != want {
+			t.Errorf("HostURL(%q) = %s, want %s", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package migrate copies the files of a hosted repository into a repository of another Nexus server,
+// typically from Nexus 2 to Nexus 3.
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bzon/nexus-cli/logging"
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// Migration copies every file of a repository to a repository of another server
+type Migration struct {
+	// Source is the client of the source server, usually a Nexus 2 server
+	Source           nexus.Client
+	SourceRepository string
+	// Target is the client of the target server, usually a Nexus 3 server
+	Target           nexus.Client
+	TargetRepository string
+	// Parallel is the number of files copied at a time
+	Parallel int
+	// StatePath is the file recording the migrated files, so that an interrupted migration resumes
+	// without asking the source for their sha1 again. No state is kept when empty.
+	StatePath string
+	// Log receives the progress messages. Nothing is logged when nil.
+	Log logging.Logger
+}
+
+// File is a file of the source repository
+type File struct {
+	Path         string `json:"path" yaml:"path"`
+	Size         int64  `json:"size" yaml:"size"`
+	LastModified string `json:"lastModified" yaml:"lastModified"`
+	Sha1         string `json:"sha1,omitempty" yaml:"sha1,omitempty"`
+}
+
+// Failure is a file that could not be migrated
+type Failure struct {
+	Path  string `json:"path" yaml:"path"`
+	Error string `json:"error" yaml:"error"`
+}
+
+// Report is the reconciliation of a migration: what was copied, and how the target compares to the source afterwards
+type Report struct {
+	Source string `json:"source" yaml:"source"`
+	Target string `json:"target" yaml:"target"`
+	// Files and Bytes count the files of the source repository
+	Files int   `json:"files" yaml:"files"`
+	Bytes int64 `json:"bytes" yaml:"bytes"`
+	// Migrated files were uploaded, Skipped files already existed in the target with the same sha1
+	Migrated []string  `json:"migrated" yaml:"migrated"`
+	Skipped  []string  `json:"skipped" yaml:"skipped"`
+	Failed   []Failure `json:"failed" yaml:"failed"`
+	// Verified counts the source files found in the target with the same sha1.
+	// Missing files are not in the target and Mismatched files have another sha1 there.
+	// Unverified files are in the target but their source sha1 could not be read.
+	Verified   int       `json:"verified" yaml:"verified"`
+	Missing    []string  `json:"missing" yaml:"missing"`
+	Mismatched []string  `json:"mismatched" yaml:"mismatched"`
+	Unverified []Failure `json:"unverified" yaml:"unverified"`
+}
+
+// OK reports whether every source file is in the target with the same sha1
+func (r *Report) OK() bool {
+	return len(r.Failed) == 0 && len(r.Missing) == 0 && len(r.Mismatched) == 0 && len(r.Unverified) == 0
+}
+
+// Run walks the source repository and uploads every file that is not in the target with the same sha1.
+// Directories starting with a dot, such as .index and .meta, are internal to Nexus 2 and skipped, as are
+// the generated files, which the target creates itself. The target is listed again at the
+// end to reconcile it with the source.
+func (m *Migration) Run() (*Report, error) {
+	if m.Log == nil {
+		m.Log = logging.Discard
+	}
+	report := &Report{
+		Source: m.Source.URL(m.SourceRepository),
+		Target: m.Target.URL(m.TargetRepository),
+	}
+	state, err := m.openState(report)
+	if err != nil {
+		return nil, err
+	}
+	m.Log.Infof("Listing %s", report.Source)
+	assets, err := m.Source.List(m.SourceRepository, "")
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, a := range assets {
+		if maven.Generated(path.Base(a.Path)) {
+			continue
+		}
+		files = append(files, File{Path: a.Path, Size: a.Size, LastModified: a.Modified})
+		report.Bytes += a.Size
+	}
+	report.Files = len(files)
+	targetSums, err := m.targetSums()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempDir("", "nexus-migrate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	parallel := m.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan File)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			dir := filepath.Join(tmp, fmt.Sprint(worker))
+			for f := range jobs {
+				sum, migrated, err := m.migrate(f, state, targetSums, dir)
+				mu.Lock()
+				switch {
+				case err != nil:
+					m.Log.Errorf("Failed migrating %s: %v", f.Path, err)
+					report.Failed = append(report.Failed, Failure{Path: f.Path, Error: err.Error()})
+				case migrated:
+					m.Log.Infof("Migrated %s", f.Path)
+					report.Migrated = append(report.Migrated, f.Path)
+				default:
+					m.Log.Debugf("%s is up to date", f.Path)
+					report.Skipped = append(report.Skipped, f.Path)
+				}
+				mu.Unlock()
+				if err == nil {
+					f.Sha1 = sum
+					if err := state.record(f); err != nil {
+						m.Log.Warnf("Failed writing %s: %v", m.StatePath, err)
+					}
+				}
+			}
+		}(i)
+	}
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	if err := state.save(); err != nil {
+		return report, err
+	}
+
+	m.Log.Infof("Reconciling %s", report.Target)
+	if targetSums, err = m.targetSums(); err != nil {
+		return report, err
+	}
+	for _, f := range files {
+		sum, found := targetSums[f.Path]
+		if !found {
+			report.Missing = append(report.Missing, f.Path)
+			continue
+		}
+		// A file being there proves nothing, it is compared with the source sha1
+		known, _ := state.lookup(f.Path)
+		if known.Sha1 == "" {
+			if known.Sha1, err = m.Source.Sha1(m.SourceRepository, f.Path); err == nil && known.Sha1 == "" {
+				err = fmt.Errorf("the source has no sha1")
+			}
+			if err != nil {
+				report.Unverified = append(report.Unverified, Failure{Path: f.Path, Error: err.Error()})
+				continue
+			}
+		}
+		if strings.EqualFold(sum, known.Sha1) {
+			report.Verified++
+		} else {
+			report.Mismatched = append(report.Mismatched, f.Path)
+		}
+	}
+	sort.Strings(report.Migrated)
+	sort.Strings(report.Skipped)
+	sort.Slice(report.Failed, func(i, j int) bool { return report.Failed[i].Path < report.Failed[j].Path })
+	return report, nil
+}
+
+// migrate copies a file unless the target already has it with the source sha1,
+// and returns the source sha1 and whether the file was uploaded
+func (m *Migration) migrate(f File, state *State, targetSums map[string]string, dir string) (string, bool, error) {
+	sum := ""
+	if known, found := state.lookup(f.Path); found && known.Size == f.Size && known.LastModified == f.LastModified {
+		sum = known.Sha1
+	} else {
+		var err error
+		if sum, err = m.Source.Sha1(m.SourceRepository, f.Path); err != nil {
+			return "", false, err
+		}
+	}
+	if sum != "" && strings.EqualFold(targetSums[f.Path], sum) {
+		return sum, false, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", false, err
+	}
+	filePath := filepath.Join(dir, path.Base(f.Path))
+	defer os.Remove(filePath)
+	downloaded, err := m.Source.Download(m.SourceRepository, f.Path, filePath)
+	if err != nil {
+		return "", false, err
+	}
+	if sum != "" && !strings.EqualFold(sum, downloaded) {
+		return "", false, fmt.Errorf("downloaded sha1 %s, source sha1 %s", downloaded, sum)
+	}
+	if err := m.Target.Upload(m.TargetRepository, f.Path, filePath); err != nil {
+		return "", false, err
+	}
+	return downloaded, true, nil
+}
+
+// targetSums returns the sha1 of every file of the target repository by path. The sha1 that the target
+// does not list are asked by Parallel workers.
+func (m *Migration) targetSums() (map[string]string, error) {
+	assets, err := m.Target.List(m.TargetRepository, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := nexus.Checksums(m.Target, m.TargetRepository, assets, m.Parallel); err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string, len(assets))
+	for _, a := range assets {
+		sums[a.Path] = a.Sha1
+	}
+	return sums, nil
+}
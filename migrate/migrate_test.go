@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := nexustest.NewServer()
+	defer server.Close()
+	for p, content := range map[string]string{
+		"com/example/foo/1.0/foo-1.0.jar":      "jar",
+		"com/example/foo/1.0/foo-1.0.pom":      "pom",
+		"com/example/foo/1.0/foo-1.0.jar.sha1": "f92e777f4341930bad9b2422283c4680d00dbc06",
+		"com/example/foo/maven-metadata.xml":   "<metadata/>",
+		".index/nexus-maven-repository-index":  "index",
+	} {
+		server.Put("releases", p, []byte(content))
+	}
+	server.Put("maven-releases", "com/example/foo/1.0/foo-1.0.pom", []byte("pom"))
+
+	// count the uploads and the sha1 descriptions, and fail describing the .zip files
+	var mu sync.Mutex
+	uploads, describes := 0, 0
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		switch {
+		case r.Method == "PUT":
+			uploads++
+		case r.URL.Query().Get("describe") == "info":
+			describes++
+		}
+		mu.Unlock()
+		if r.URL.Query().Get("describe") == "info" && strings.HasSuffix(r.URL.Path, ".zip") {
+			http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	s := nexus.Server{HostURL: server.URL, Username: server.Username, Password: server.Password}
+	source, _ := nexus.New(2, s)
+	target, _ := nexus.New(3, s)
+	m := Migration{
+		Source:           source,
+		SourceRepository: "releases",
+		Target:           target,
+		TargetRepository: "maven-releases",
+		Parallel:         2,
+		StatePath:        filepath.Join(dir, "state.json"),
+	}
+	report, err := m.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Files != 2 || report.Verified != 2 {
+		t.Errorf("report = %+v, want 2 verified files", report)
+	}
+	if len(report.Migrated) != 1 || report.Migrated[0] != "com/example/foo/1.0/foo-1.0.jar" || len(report.Skipped) != 1 {
+		t.Errorf("Migrated = %v, Skipped = %v, want the jar migrated and the pom skipped", report.Migrated, report.Skipped)
+	}
+	if jar, _ := server.Get("maven-releases", "com/example/foo/1.0/foo-1.0.jar"); uploads != 1 || string(jar) != "jar" {
+		t.Errorf("uploads = %d, target jar = %q", uploads, jar)
+	}
+
+	// a file of the target whose source sha1 cannot be read is not verified by its presence
+	server.Put("releases", "com/example/foo/1.0/foo-1.0-docs.zip", []byte("docs"))
+	server.Put("maven-releases", "com/example/foo/1.0/foo-1.0-docs.zip", []byte("other docs"))
+	unverified, err := m.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unverified.OK() || unverified.Verified != 2 || len(unverified.Unverified) != 1 || unverified.Unverified[0].Path != "com/example/foo/1.0/foo-1.0-docs.zip" {
+		t.Errorf("report = %+v, want the docs unverified", unverified)
+	}
+	server.Delete("releases", "com/example/foo/1.0/foo-1.0-docs.zip")
+
+	describes = 0
+	if report, err = m.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if describes != 0 || uploads != 1 || len(report.Skipped) != 2 {
+		t.Errorf("resumed run: %d describes, %d uploads, report %+v, want everything skipped from the state", describes, uploads, report)
+	}
+
+	m.TargetRepository = "other"
+	if _, err := m.Run(); err == nil {
+		t.Error("a state of another target should be refused")
+	}
+}
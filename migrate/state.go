@@ -0,0 +1,105 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// saveEvery is the number of recorded files after which the state file is written again
+const saveEvery = 100
+
+// State records the files already migrated by path, with the size and modification time
+// they had in the source so that a changed file is migrated again
+type State struct {
+	Source string          `json:"source"`
+	Target string          `json:"target"`
+	Files  map[string]File `json:"files"`
+
+	path    string
+	mu      sync.Mutex
+	unsaved int
+}
+
+// ReadState reads a state file, a missing file is an empty state
+func ReadState(path string) (*State, error) {
+	state := &State{Files: map[string]File{}, path: path}
+	if path == "" {
+		return state, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]File{}
+	}
+	return state, nil
+}
+
+// openState reads the state file of the migration and refuses a state of another migration
+func (m *Migration) openState(report *Report) (*State, error) {
+	state, err := ReadState(m.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Source == "" && state.Target == "" {
+		state.Source, state.Target = report.Source, report.Target
+	}
+	if state.Source != report.Source || state.Target != report.Target {
+		return nil, fmt.Errorf("%s records the migration of %s to %s, not %s to %s",
+			m.StatePath, state.Source, state.Target, report.Source, report.Target)
+	}
+	return state, nil
+}
+
+// lookup returns the recorded file of a path
+func (s *State) lookup(path string) (File, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, found := s.Files[path]
+	return f, found
+}
+
+// record marks a file as migrated and writes the state file every saveEvery files
+func (s *State) record(f File) error {
+	s.mu.Lock()
+	s.Files[f.Path] = f
+	s.unsaved++
+	due := s.unsaved >= saveEvery
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return s.save()
+}
+
+// save writes the state file
+func (s *State) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || s.unsaved == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.unsaved = 0
+	return nil
+}
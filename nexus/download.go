@@ -0,0 +1,177 @@
+package nexus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bzon/nexus-cli/nexus2"
+)
+
+// ArtifactCache keeps downloaded artifacts by sha1 and the last resolution of every artifact
+type ArtifactCache interface {
+	// Copy copies the cached file of a sha1 to filePath and reports false when there is none
+	Copy(sha1, filePath string) (bool, error)
+	// Put adds a verified file to the cache
+	Put(sha1, filePath, name string) error
+	// PutResolution saves the resolution metadata of a key
+	PutResolution(key string, b []byte) error
+	// Resolution returns the last resolution metadata saved for a key
+	Resolution(key string) ([]byte, bool, error)
+}
+
+// Cache keeps the resolutions of the clients and is looked up before every download when it is not nil
+var Cache ArtifactCache
+
+// Offline answers resolutions and downloads from the Cache only, without sending any request
+var Offline bool
+
+// ErrNotCached is wrapped by the errors of offline resolutions and downloads that are not in the Cache
+var ErrNotCached = errors.New("not in the offline cache")
+
+// Request is an artifact to download
+type Request struct {
+	Coordinates
+	// Repository defaults to snapshots for a -SNAPSHOT version and to releases otherwise
+	Repository string
+	// Dir is the directory where to place the file
+	Dir string
+	// Filename replaces the file name of the repository, <artifact>-<version>[-<classifier>].<extension>
+	Filename string
+	// Sha1 is the expected sha1 of the artifact, checked when set
+	Sha1 string
+}
+
+// DefaultRepository returns the repository of a Request without Repository
+func DefaultRepository(version string) string {
+	return nexus2.DefaultRepository(version)
+}
+
+// repository returns the repository of the request
+func (r Request) repository() string {
+	if r.Repository == "" {
+		return DefaultRepository(r.Version)
+	}
+	return r.Repository
+}
+
+// ResolveArtifact resolves the artifact of a Request in its repository
+func ResolveArtifact(c Client, r Request) (*Resolution, error) {
+	return c.Resolve(r.repository(), r.Coordinates)
+}
+
+// DownloadArtifact resolves the artifact of a Request and downloads it
+func DownloadArtifact(c Client, r Request) (string, error) {
+	res, err := ResolveArtifact(c, r)
+	if err != nil {
+		return "", err
+	}
+	return DownloadResolved(c, r, res)
+}
+
+// DownloadResolved downloads an artifact that was already resolved with ResolveArtifact into the directory
+// of the Request and validates its sha1. The file of the Cache is used instead when it has one.
+func DownloadResolved(c Client, r Request, res *Resolution) (string, error) {
+	// Check the expected sha1 before downloading anything
+	if r.Sha1 != "" && !strings.EqualFold(r.Sha1, res.Sha1) {
+		return "", fmt.Errorf("Download error. Expected sha1 %s but Nexus resolved %s", r.Sha1, res.Sha1)
+	}
+	fileName := r.Filename
+	if fileName == "" {
+		fileName = path.Base(res.Path)
+	}
+	filePath := filepath.Join(r.Dir, fileName)
+
+	// Use the cached file of the resolved sha1 when there is one
+	if Cache != nil && res.Sha1 != "" {
+		hit, err := Cache.Copy(res.Sha1, filePath)
+		if err != nil && Offline {
+			return "", err
+		}
+		if err != nil {
+			log.Warnf("Cannot use the cached file of %s: %v", res.Sha1, err)
+		} else if hit {
+			log.Infof("Successfully copied the cached file %s", filePath)
+			return filePath, nil
+		}
+	}
+	if Offline {
+		return "", fmt.Errorf("the file %s (sha1 %s) is %w", fileName, res.Sha1, ErrNotCached)
+	}
+
+	log.Infof("Downloading file %s:%s:%s:%s", res.Group, res.Artifact, res.Version, res.Extension)
+	log.Debugf("Writing %s", filePath)
+	// Replace the file instead of writing through it, it may be a hard link to another file
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	sum, err := c.Download(r.repository(), res.Path, filePath)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("Got remote sha1 %s and downloaded sha1 %s", res.Sha1, sum)
+	if !strings.EqualFold(res.Sha1, sum) {
+		os.Remove(filePath)
+		return "", fmt.Errorf("Download error. There is a mismatch in sha1sum")
+	}
+
+	log.Infof("Successfully downloaded the file %s", filePath)
+	if Cache != nil {
+		if err := Cache.Put(sum, filePath, fileName); err != nil {
+			log.Warnf("Cannot cache %s: %v", filePath, err)
+		}
+	}
+	return filePath, nil
+}
+
+// cachedResolve answers the resolution of coordinates from the Cache in Offline mode. Otherwise it
+// resolves them and saves the resolution, also under the resolved version so that locked requests find it.
+func cachedResolve(hostURL, repository string, co Coordinates, resolve func() (*Resolution, error)) (*Resolution, error) {
+	key := resolutionKey(hostURL, repository, co)
+	if Offline {
+		if Cache == nil {
+			return nil, fmt.Errorf("the resolution of %s:%s:%s:%s is %w", co.Group, co.Artifact, co.Version, co.Extension, ErrNotCached)
+		}
+		b, found, err := Cache.Resolution(key)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("the resolution of %s:%s:%s:%s is %w", co.Group, co.Artifact, co.Version, co.Extension, ErrNotCached)
+		}
+		res := new(Resolution)
+		if err := json.Unmarshal(b, res); err != nil {
+			return nil, fmt.Errorf("the cached resolution of %s: %v", key, err)
+		}
+		log.Debugf("Using the cached resolution of %s", key)
+		return res, nil
+	}
+
+	res, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+	if Cache != nil {
+		b, err := json.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+		resolved := co
+		resolved.Version = res.Version
+		for _, key := range []string{key, resolutionKey(hostURL, repository, resolved)} {
+			if err := Cache.PutResolution(key, b); err != nil {
+				log.Warnf("Cannot cache the resolution of %s: %v", key, err)
+			}
+		}
+	}
+	return res, nil
+}
+
+// resolutionKey identifies the resolution of coordinates: host, repository and coordinates
+func resolutionKey(hostURL, repository string, co Coordinates) string {
+	return strings.Join([]string{hostURL, repository, co.Group, co.Artifact, co.Version, co.Classifier, co.Extension}, ":")
+}
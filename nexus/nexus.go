@@ -0,0 +1,318 @@
+// Package nexus provides a single client over the repositories of Nexus 2 and Nexus 3 servers.
+//
+// A Client resolves, downloads, uploads, searches, lists and deletes the files of a repository
+// by their path in the Maven layout, whatever the major version of the server:
+//
+//	server := nexus.Server{HostURL: "http://localhost:8081", Username: "admin", Password: "admin123"}
+//	version, err := nexus.Detect(server)
+//	client, err := nexus.New(version, server)
+//	resolution, err := client.Resolve("releases", nexus.Coordinates{Group: "com.example", Artifact: "app", Version: "LATEST"})
+//
+// DownloadArtifact downloads the artifact of a Request through the Cache and verifies its sha1.
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/logging"
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus2"
+	"github.com/bzon/nexus-cli/nexus3"
+)
+
+// ErrUnauthorized is returned by CheckCredentials when the server rejects the credentials
+var ErrUnauthorized = errors.New("the credentials were rejected by Nexus")
+
+// ErrNotFound is wrapped by the errors of the files that a repository does not have
+var ErrNotFound = errors.New("not found")
+
+// log receives the progress messages of the package
+var log, _ = logging.New(os.Stderr, logging.LevelInfo, "text")
+
+// SetLogger replaces the logger receiving the progress messages of the package.
+// A nil logger discards every message.
+func SetLogger(l logging.Logger) {
+	if l == nil {
+		l = logging.Discard
+	}
+	log = l
+}
+
+// Server is the host url and the credentials of a Nexus server
+type Server struct {
+	HostURL, Username, Password string
+	// Auth authenticates the requests. Basic authentication with Username and Password is used when nil.
+	Auth auth.Authenticator
+	// HTTPClient executes the requests, with the TLS settings and transport of the server. The default
+	// clients of the nexus2 and nexus3 packages are used when nil.
+	HTTPClient *http.Client
+}
+
+// Coordinates identify a Maven artifact
+type Coordinates struct {
+	Group, Artifact string
+	// Version is LATEST, RELEASE, a -SNAPSHOT version resolving to its newest build, or an exact version
+	Version string
+	// Classifier is optional and Extension defaults to jar
+	Classifier, Extension string
+}
+
+// Resolution is the file of a repository that Coordinates resolve to
+type Resolution struct {
+	Coordinates
+	// BaseVersion is the version directory, ending with -SNAPSHOT for the timestamped Version of a snapshot build
+	BaseVersion string
+	Snapshot    bool
+	// BuildNumber and Timestamp identify the build of a snapshot
+	BuildNumber int
+	Timestamp   time.Time
+	Path, Sha1  string
+}
+
+// Asset is a file of a repository
+type Asset struct {
+	Path string `json:"path" yaml:"path"`
+	// Sha1 is empty when the server does not list checksums, see Checksums
+	Sha1 string `json:"sha1" yaml:"sha1"`
+	Size int64  `json:"size" yaml:"size"`
+	// Modified is the last modification time reported by the server, empty when it reports none
+	Modified string `json:"modified,omitempty" yaml:"modified,omitempty"`
+}
+
+// Component is a Maven component: the files of a release version, or of a snapshot build
+type Component struct {
+	// ID identifies the component on Nexus 3 and is empty on Nexus 2, which has no components
+	ID       string  `json:"-" yaml:"-"`
+	Group    string  `json:"group" yaml:"group"`
+	Artifact string  `json:"artifact" yaml:"artifact"`
+	Version  string  `json:"version" yaml:"version"`
+	Assets   []Asset `json:"assets" yaml:"assets"`
+}
+
+// Client reads and writes the repositories of a Nexus server. Paths are relative to the repository root.
+type Client interface {
+	// Version returns the major version of the server, 2 or 3
+	Version() int
+	// URL returns the url of a repository, below which its files are read with GET and written with PUT
+	URL(repository string) string
+	// CheckCredentials returns ErrUnauthorized when the server rejects the credentials
+	CheckCredentials() error
+	// Resolve returns the file of an artifact. The resolution is answered from the Cache in Offline mode,
+	// and saved in the Cache otherwise.
+	Resolve(repository string, c Coordinates) (*Resolution, error)
+	// Sha1 returns the sha1 that the server stores for a file, or an error wrapping ErrNotFound
+	Sha1(repository, path string) (string, error)
+	// Download writes a file to filePath and returns its sha1, or an error wrapping ErrNotFound
+	Download(repository, path, filePath string) (string, error)
+	// Upload stores the content of filePath as path
+	Upload(repository, path, filePath string) error
+	// Search returns the components of a group, an artifact and a version, where an empty value matches
+	// anything and a -SNAPSHOT version matches its builds
+	Search(repository, group, artifact, version string) ([]Component, error)
+	// List returns the files below a directory, or of the whole repository when directory is empty.
+	// A directory that does not exist has no files.
+	List(repository, directory string) ([]Asset, error)
+	// Delete removes a file, or every file below a directory
+	Delete(repository, path string) error
+	// DeleteComponent removes the files of a component returned by Search
+	DeleteComponent(repository string, c Component) error
+	// RebuildMetadata rebuilds the maven-metadata.xml files of a directory and of its subdirectories
+	RebuildMetadata(repository, directory string) error
+	// Promote copies the files of the group:artifact:version component from one repository to another and
+	// verifies every sha1 in the target. With deleteSource, the component is then deleted from the source.
+	Promote(from, to, group, artifact, version string, deleteSource bool) ([]Asset, error)
+}
+
+// New returns the client of a server of major version 2 or 3
+func New(version int, s Server) (Client, error) {
+	switch version {
+	case 2:
+		return &nexus2Client{s}, nil
+	case 3:
+		return &nexus3Client{s}, nil
+	}
+	return nil, fmt.Errorf("unsupported Nexus version %d, expected 2 or 3", version)
+}
+
+// Detect returns the major version of a server: 3 when it has the Nexus 3 status endpoint and 2 when
+// it has the Nexus 2 login endpoint. The requests are anonymous, so that wrong credentials cannot
+// hide an endpoint behind a 401.
+func Detect(s Server) (int, error) {
+	client := nexus3.Client{HostURL: s.HostURL, Auth: auth.Anonymous{}, HTTPClient: s.HTTPClient}
+	err := client.CheckCredentials()
+	if err == nil || err == nexus3.ErrUnauthorized {
+		return 3, nil
+	}
+	err = nexus2.Login(nexus2.ArtifactRequest{HostURL: s.HostURL, Auth: auth.Anonymous{}, HTTPClient: s.HTTPClient})
+	if err == nil || err == nexus2.ErrUnauthorized {
+		return 2, nil
+	}
+	return 0, fmt.Errorf("%s is neither a Nexus 3 nor a Nexus 2 server: %v", s.HostURL, err)
+}
+
+// Checksums fills the missing sha1 of the assets of a repository, asked by parallel workers
+func Checksums(c Client, repository string, assets []Asset, parallel int) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+	var errs []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sum, err := c.Sha1(repository, assets[i].Path)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", assets[i].Path, err))
+				} else {
+					assets[i].Sha1 = sum
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range assets {
+		if assets[i].Sha1 == "" {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("%d files failed:\n%s", len(errs), strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// buildName matches the <timestamp>-<build number> that follows <artifact>-<version without SNAPSHOT>
+// in the file names of a snapshot build
+var buildName = regexp.MustCompile(`^\d{8}\.\d{6}-\d+`)
+
+// components groups the assets of a repository in the Maven layout into components by version
+// directory, and the assets of a snapshot directory by build. Files without the artifact-version
+// prefix, such as the maven-metadata.xml of a snapshot directory, belong to no component.
+func components(assets []Asset) []Component {
+	byKey := map[string]*Component{}
+	var keys []string
+	for _, a := range assets {
+		parts := strings.Split(a.Path, "/")
+		if len(parts) < 4 {
+			continue
+		}
+		artifact, version, name := parts[len(parts)-3], parts[len(parts)-2], parts[len(parts)-1]
+		prefix := artifact + "-" + version
+		if strings.HasSuffix(version, "-SNAPSHOT") {
+			prefix = artifact + "-" + strings.TrimSuffix(version, "SNAPSHOT")
+			build := buildName.FindString(strings.TrimPrefix(name, prefix))
+			if build != "" {
+				version = strings.TrimSuffix(version, "SNAPSHOT") + build
+				prefix = artifact + "-" + version
+			}
+		}
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := path.Dir(a.Path) + ":" + version
+		c, found := byKey[key]
+		if !found {
+			c = &Component{Group: strings.Join(parts[:len(parts)-3], "."), Artifact: artifact, Version: version}
+			byKey[key] = c
+			keys = append(keys, key)
+		}
+		c.Assets = append(c.Assets, a)
+	}
+	list := make([]Component, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, *byKey[key])
+	}
+	return list
+}
+
+// match reports whether a component has a group, an artifact and a version, where an empty value
+// matches anything and a -SNAPSHOT version matches its builds
+func match(c Component, group, artifact, version string) bool {
+	return (group == "" || c.Group == group) && (artifact == "" || c.Artifact == artifact) &&
+		(version == "" || c.Version == version || maven.BaseVersion(c.Version) == version)
+}
+
+// newest returns the newest component of a version: LATEST matches any version, RELEASE any
+// release, a -SNAPSHOT version any of its builds and any other version only itself
+func newest(components []Component, version string) (Component, bool) {
+	var best Component
+	found := false
+	for _, c := range components {
+		snapshot := strings.HasSuffix(maven.BaseVersion(c.Version), "-SNAPSHOT")
+		switch version {
+		case "LATEST":
+		case "RELEASE":
+			if snapshot {
+				continue
+			}
+		default:
+			if !match(c, "", "", version) {
+				continue
+			}
+		}
+		if !found || newer(c.Version, best.Version) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+// newer reports whether version a is newer than version b. The builds of a snapshot are ordered by
+// timestamp and build number.
+func newer(a, b string) bool {
+	if cmp := maven.CompareVersions(maven.BaseVersion(a), maven.BaseVersion(b)); cmp != 0 {
+		return cmp > 0
+	}
+	aTime, aBuild, _ := maven.SnapshotBuild(a)
+	bTime, bBuild, _ := maven.SnapshotBuild(b)
+	if !aTime.Equal(bTime) {
+		return aTime.After(bTime)
+	}
+	return aBuild > bBuild
+}
+
+// resolve returns the resolution of coordinates among the components of an artifact
+func resolve(components []Component, c Coordinates) (*Resolution, error) {
+	if c.Extension == "" {
+		c.Extension = "jar"
+	}
+	gav := c.Group + ":" + c.Artifact + ":" + c.Version
+	component, found := newest(components, c.Version)
+	if !found {
+		return nil, fmt.Errorf("%s not found", gav)
+	}
+	name := c.Artifact + "-" + component.Version
+	if c.Classifier != "" {
+		name += "-" + c.Classifier
+	}
+	name += "." + c.Extension
+	for _, a := range component.Assets {
+		if path.Base(a.Path) != name {
+			continue
+		}
+		res := &Resolution{Coordinates: c, BaseVersion: maven.BaseVersion(component.Version), Path: a.Path, Sha1: a.Sha1}
+		res.Version = component.Version
+		res.Snapshot = strings.HasSuffix(res.BaseVersion, "-SNAPSHOT")
+		res.Timestamp, res.BuildNumber, _ = maven.SnapshotBuild(component.Version)
+		return res, nil
+	}
+	return nil, fmt.Errorf("%s has no file %s", gav, name)
+}
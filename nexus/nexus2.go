@@ -0,0 +1,161 @@
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus2"
+)
+
+// nexus2Client is the Client of a Nexus 2 server, built on its maven and content endpoints
+type nexus2Client struct {
+	server Server
+}
+
+// request returns an artifact request carrying the host url, credentials and HTTP client of the server
+func (c *nexus2Client) request() nexus2.ArtifactRequest {
+	s := c.server
+	return nexus2.ArtifactRequest{HostURL: s.HostURL, Username: s.Username, Password: s.Password, Auth: s.Auth, HTTPClient: s.HTTPClient}
+}
+
+func (c *nexus2Client) Version() int {
+	return 2
+}
+
+func (c *nexus2Client) URL(repository string) string {
+	return c.server.HostURL + nexus2.ContentPath + repository
+}
+
+func (c *nexus2Client) CheckCredentials() error {
+	if err := nexus2.Login(c.request()); err != nexus2.ErrUnauthorized {
+		return err
+	}
+	return ErrUnauthorized
+}
+
+// Resolve asks the maven resolve endpoint
+func (c *nexus2Client) Resolve(repository string, co Coordinates) (*Resolution, error) {
+	if co.Extension == "" {
+		co.Extension = "jar"
+	}
+	return cachedResolve(c.server.HostURL, repository, co, func() (*Resolution, error) {
+		aRequest := c.request()
+		aRequest.RepositoryID = repository
+		aRequest.GroupID, aRequest.Artifact, aRequest.Version = co.Group, co.Artifact, co.Version
+		aRequest.Packaging, aRequest.Classifier, aRequest.Extension = co.Extension, co.Classifier, co.Extension
+		aResolution, err := nexus2.GetArtifactResolution(aRequest)
+		if err != nil {
+			return nil, err
+		}
+		data := aResolution.Data
+		res := &Resolution{
+			Coordinates: co,
+			BaseVersion: data.BaseVersion,
+			Snapshot:    data.Snapshot,
+			BuildNumber: data.SnapshotBuildNumber,
+			Path:        strings.TrimPrefix(data.RepositoryPath, "/"),
+			Sha1:        data.Sha1,
+		}
+		res.Version = data.Version
+		if data.SnapshotTimeStamp != 0 {
+			res.Timestamp = time.Unix(0, int64(data.SnapshotTimeStamp)*int64(time.Millisecond)).UTC()
+		}
+		return res, nil
+	})
+}
+
+func (c *nexus2Client) Sha1(repository, path string) (string, error) {
+	sum, err := nexus2.ContentSha1(c.request(), repository, path)
+	return sum, contentError(err, repository, path)
+}
+
+func (c *nexus2Client) Download(repository, path, filePath string) (string, error) {
+	sum, err := nexus2.GetContent(c.request(), repository, path, filePath)
+	return sum, contentError(err, repository, path)
+}
+
+func (c *nexus2Client) Upload(repository, path, filePath string) error {
+	return nexus2.PutContent(c.request(), repository, path, filePath)
+}
+
+// Search lists the directory of the group and artifact, and groups its files into components
+func (c *nexus2Client) Search(repository, group, artifact, version string) ([]Component, error) {
+	dir := ""
+	if group != "" {
+		dir = strings.Replace(group, ".", "/", -1)
+		if artifact != "" {
+			dir += "/" + artifact
+		}
+	}
+	assets, err := c.List(repository, dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []Component
+	for _, component := range components(assets) {
+		if match(component, group, artifact, version) {
+			found = append(found, component)
+		}
+	}
+	return found, nil
+}
+
+// List walks the content listing, which carries the size but not the sha1 of the files
+func (c *nexus2Client) List(repository, directory string) ([]Asset, error) {
+	dir := strings.Trim(directory, "/")
+	if dir != "" {
+		dir += "/"
+	}
+	files, err := nexus2.ListFiles(c.request(), repository, dir)
+	if dir != "" && errors.Is(err, nexus2.ErrContentNotFound) {
+		return []Asset{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]Asset, 0, len(files))
+	for _, f := range files {
+		assets = append(assets, Asset{Path: f.RelativePath, Size: f.SizeOnDisk, Modified: f.LastModified})
+	}
+	return assets, nil
+}
+
+func (c *nexus2Client) Delete(repository, path string) error {
+	return contentError(nexus2.DeleteContent(c.request(), repository, path), repository, path)
+}
+
+// DeleteComponent deletes the files of the component one by one, Nexus 2 having no components
+func (c *nexus2Client) DeleteComponent(repository string, component Component) error {
+	for _, a := range component.Assets {
+		if err := c.Delete(repository, a.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *nexus2Client) RebuildMetadata(repository, directory string) error {
+	return nexus2.RebuildMetadata(c.request(), repository, directory)
+}
+
+// Promote copies the files of the version directory through the content paths
+func (c *nexus2Client) Promote(from, to, group, artifact, version string, deleteSource bool) ([]Asset, error) {
+	aRequest := c.request()
+	aRequest.GroupID, aRequest.Artifact, aRequest.Version = group, artifact, version
+	files, err := nexus2.PromoteComponent(aRequest, from, to, deleteSource)
+	var promoted []Asset
+	for _, f := range files {
+		promoted = append(promoted, Asset{Path: f.Path, Sha1: f.Sha1, Size: f.Size})
+	}
+	return promoted, err
+}
+
+// contentError wraps ErrNotFound around the errors of the files that a repository does not have
+func contentError(err error, repository, path string) error {
+	if errors.Is(err, nexus2.ErrContentNotFound) {
+		return fmt.Errorf("%s %w in %s", strings.Trim(path, "/"), ErrNotFound, repository)
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/bzon/nexus-cli/nexus3"
+)
+
+// nexus3Client is the Client of a Nexus 3 server, built on its REST API and repository urls
+type nexus3Client struct {
+	server Server
+}
+
+// client returns the nexus3 client of a repository
+func (c *nexus3Client) client(repository string) *nexus3.Client {
+	s := c.server
+	return &nexus3.Client{Repository: repository, HostURL: s.HostURL, Username: s.Username, Password: s.Password, Auth: s.Auth, HTTPClient: s.HTTPClient}
+}
+
+func (c *nexus3Client) Version() int {
+	return 3
+}
+
+func (c *nexus3Client) URL(repository string) string {
+	return c.client(repository).GetRepoURL()
+}
+
+func (c *nexus3Client) CheckCredentials() error {
+	if err := c.client("").CheckCredentials(); err != nexus3.ErrUnauthorized {
+		return err
+	}
+	return ErrUnauthorized
+}
+
+// Resolve searches the components of the artifact, Nexus 3 having no resolve endpoint
+func (c *nexus3Client) Resolve(repository string, co Coordinates) (*Resolution, error) {
+	if co.Extension == "" {
+		co.Extension = "jar"
+	}
+	return cachedResolve(c.server.HostURL, repository, co, func() (*Resolution, error) {
+		log.Infof("Resolving the artifact %s:%s:%s:%s", co.Group, co.Artifact, co.Version, co.Extension)
+		version := co.Version
+		if version == "LATEST" || version == "RELEASE" {
+			version = ""
+		}
+		found, err := c.Search(repository, co.Group, co.Artifact, version)
+		if err != nil {
+			return nil, err
+		}
+		res, err := resolve(found, co)
+		if err != nil {
+			return nil, fmt.Errorf("%v in %s", err, repository)
+		}
+		return res, nil
+	})
+}
+
+// Sha1 searches the asset of the path
+func (c *nexus3Client) Sha1(repository, p string) (string, error) {
+	assets, err := c.findAssets(repository, p, false)
+	if err != nil {
+		return "", err
+	}
+	return assets[0].Checksum.Sha1, nil
+}
+
+func (c *nexus3Client) Download(repository, p, filePath string) (string, error) {
+	a := nexus3.Asset{Path: p, DownloadURL: c.URL(repository) + "/" + strings.TrimPrefix(p, "/")}
+	sum, err := c.client(repository).DownloadAsset(a, filePath)
+	if errors.Is(err, nexus3.ErrNotFound) {
+		return "", fmt.Errorf("%s %w in %s", strings.Trim(p, "/"), ErrNotFound, repository)
+	}
+	return sum, err
+}
+
+func (c *nexus3Client) Upload(repository, p, filePath string) error {
+	_, err := c.client(repository).SiteFileUpload(nexus3.SiteComponent{File: filePath, Filename: path.Base(p), Directory: directory(p)})
+	return err
+}
+
+// Search sends the group, name and base version to the search API. The versions are matched
+// again, as the search API of older Nexus 3 versions ignores maven.baseVersion.
+func (c *nexus3Client) Search(repository, group, artifact, version string) ([]Component, error) {
+	query := url.Values{}
+	if group != "" {
+		query.Set("group", group)
+	}
+	if artifact != "" {
+		query.Set("name", artifact)
+	}
+	switch {
+	case strings.HasSuffix(version, "-SNAPSHOT"):
+		query.Set("maven.baseVersion", version)
+	case version != "":
+		query.Set("version", version)
+	}
+	list, err := c.client(repository).SearchComponents(query)
+	if err != nil {
+		return nil, err
+	}
+	var found []Component
+	for _, nc := range list {
+		component := Component{ID: nc.ID, Group: nc.Group, Artifact: nc.Name, Version: nc.Version}
+		for _, a := range nc.Assets {
+			component.Assets = append(component.Assets, asset(a))
+		}
+		if match(component, group, artifact, version) {
+			found = append(found, component)
+		}
+	}
+	return found, nil
+}
+
+func (c *nexus3Client) List(repository, dir string) ([]Asset, error) {
+	list, err := c.client(repository).ListAssets(dir)
+	if err != nil {
+		return nil, err
+	}
+	assets := make([]Asset, 0, len(list))
+	for _, a := range list {
+		assets = append(assets, asset(a))
+	}
+	return assets, nil
+}
+
+// Delete deletes the assets of the path one by one, Nexus 3 having no directories
+func (c *nexus3Client) Delete(repository, p string) error {
+	client := c.client(repository)
+	list, err := c.findAssets(repository, p, true)
+	if err != nil {
+		return err
+	}
+	for _, a := range list {
+		if err := client.DeleteAsset(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteComponent deletes the component by id
+func (c *nexus3Client) DeleteComponent(repository string, component Component) error {
+	if component.ID == "" {
+		return fmt.Errorf("%s:%s:%s has no component id in %s", component.Group, component.Artifact, component.Version, repository)
+	}
+	return c.client(repository).DeleteComponent(nexus3.Component{ID: component.ID})
+}
+
+// RebuildMetadata does nothing, Nexus 3 keeps the metadata of its Maven repositories up to date itself
+func (c *nexus3Client) RebuildMetadata(repository, directory string) error {
+	return nil
+}
+
+// Promote uploads the assets of the component with the components API
+func (c *nexus3Client) Promote(from, to, group, artifact, version string, deleteSource bool) ([]Asset, error) {
+	list, err := c.client(from).PromoteComponent(group, artifact, version, to, deleteSource)
+	var promoted []Asset
+	for _, a := range list {
+		promoted = append(promoted, Asset{Path: a.Path, Sha1: a.Sha1, Size: a.Size})
+	}
+	return promoted, err
+}
+
+// findAssets searches the asset of a path, and with below the assets of the paths below it, instead of
+// listing the whole repository. Maven assets are searched by the coordinates that the path would have
+// as a file, a version directory or an artifact directory, raw assets by name, which is their path.
+// The results are filtered by path, so the coordinates of a path that is not in the Maven layout match nothing.
+// The files that belong to no component, such as the maven-metadata.xml that Nexus 3 maintains, are not found.
+func (c *nexus3Client) findAssets(repository, p string, below bool) ([]nexus3.Asset, error) {
+	p = strings.Trim(p, "/")
+	parts := strings.Split(p, "/")
+	n := len(parts)
+	var queries []url.Values
+	if n >= 4 && strings.HasPrefix(parts[n-1], parts[n-3]+"-") {
+		queries = append(queries, mavenQuery(parts[:n-3], parts[n-3], parts[n-2]))
+	}
+	queries = append(queries, url.Values{"name": {p}})
+	if below {
+		if n >= 3 {
+			queries = append(queries, mavenQuery(parts[:n-2], parts[n-2], parts[n-1]))
+		}
+		if n >= 2 {
+			queries = append(queries, mavenQuery(parts[:n-1], parts[n-1], ""))
+		}
+		queries = append(queries, url.Values{"name": {p + "/*"}})
+	}
+	client := c.client(repository)
+	seen := map[string]bool{}
+	var found []nexus3.Asset
+	for _, query := range queries {
+		list, err := client.SearchAssets(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range list {
+			if (a.Path == p || below && strings.HasPrefix(a.Path, p+"/")) && !seen[a.Path] {
+				seen[a.Path] = true
+				found = append(found, a)
+			}
+		}
+		// a file has a single asset, the assets below a directory may belong to several components
+		if len(found) > 0 && !below {
+			break
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s %w in %s", p, ErrNotFound, repository)
+	}
+	return found, nil
+}
+
+// mavenQuery returns the search of the assets of a group, an artifact and a base version, any version when empty
+func mavenQuery(group []string, artifact, baseVersion string) url.Values {
+	query := url.Values{"maven.groupId": {strings.Join(group, ".")}, "maven.artifactId": {artifact}}
+	if baseVersion != "" {
+		query.Set("maven.baseVersion", baseVersion)
+	}
+	return query
+}
+
+// asset converts an asset of the nexus3 package
+func asset(a nexus3.Asset) Asset {
+	return Asset{Path: strings.TrimPrefix(a.Path, "/"), Sha1: a.Checksum.Sha1, Size: a.FileSize, Modified: a.LastModified}
+}
+
+// directory returns the directory of a path, empty at the repository root
+func directory(p string) string {
+	dir := path.Dir(strings.Trim(p, "/"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,210 @@
+package nexus
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bzon/nexus-cli/nexus3"
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jar := filepath.Join(dir, "app.jar")
+	ioutil.WriteFile(jar, []byte("jar"), 0644)
+
+	for _, version := range []int{2, 3} {
+		server := nexustest.NewServer()
+		server.Version = version
+		for _, p := range []string{
+			"com/example/app/1.0/app-1.0.jar",
+			"com/example/app/1.10/app-1.10.jar",
+			"com/example/app/2.0-SNAPSHOT/app-2.0-20181031.101010-1.jar",
+			"com/example/app/2.0-SNAPSHOT/app-2.0-20181031.121212-2.jar",
+			"com/example/app/2.0-SNAPSHOT/maven-metadata.xml",
+		} {
+			server.Put("releases", p, []byte(p))
+		}
+		s := Server{HostURL: server.URL, Username: server.Username, Password: server.Password}
+
+		detected, err := Detect(s)
+		if err != nil || detected != version {
+			t.Errorf("Detect() = %d, %v, want %d", detected, err, version)
+		}
+		c, err := New(version, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for v, want := range map[string]string{
+			"LATEST":       "com/example/app/2.0-SNAPSHOT/app-2.0-20181031.121212-2.jar",
+			"RELEASE":      "com/example/app/1.10/app-1.10.jar",
+			"1.0":          "com/example/app/1.0/app-1.0.jar",
+			"2.0-SNAPSHOT": "com/example/app/2.0-SNAPSHOT/app-2.0-20181031.121212-2.jar",
+		} {
+			res, err := c.Resolve("releases", Coordinates{Group: "com.example", Artifact: "app", Version: v})
+			if err != nil || res.Path != want || res.Sha1 == "" {
+				t.Errorf("Nexus %d: Resolve(%s) = %+v, %v, want %s", version, v, res, err, want)
+			}
+		}
+		if res, err := c.Resolve("releases", Coordinates{Group: "com.example", Artifact: "app", Version: "2.0-SNAPSHOT"}); err != nil ||
+			!res.Snapshot || res.BuildNumber != 2 || res.Timestamp.Hour() != 12 {
+			t.Errorf("Nexus %d: Resolve(2.0-SNAPSHOT) = %+v, %v", version, res, err)
+		}
+		if _, err := c.Resolve("releases", Coordinates{Group: "com.example", Artifact: "app", Version: "3.0"}); err == nil {
+			t.Errorf("Nexus %d: Resolve(3.0) should fail", version)
+		}
+
+		if err := c.Upload("releases", "com/example/lib/1.0/lib-1.0.jar", jar); err != nil {
+			t.Fatal(err)
+		}
+		sum, err := c.Download("releases", "com/example/lib/1.0/lib-1.0.jar", filepath.Join(dir, "lib.jar"))
+		if err != nil || sum != "f92e777f4341930bad9b2422283c4680d00dbc06" {
+			t.Errorf("Nexus %d: Download() = %s, %v", version, sum, err)
+		}
+		found, err := c.Search("releases", "com.example", "app", "2.0-SNAPSHOT")
+		if err != nil || len(found) != 2 || found[0].Version != "2.0-20181031.101010-1" || len(found[0].Assets) != 1 {
+			t.Errorf("Nexus %d: Search() = %+v, %v", version, found, err)
+		}
+		assets, err := c.List("releases", "com/example/lib")
+		if err != nil || len(assets) != 1 || assets[0].Size != 3 {
+			t.Errorf("Nexus %d: List() = %+v, %v", version, assets, err)
+		}
+		if err := Checksums(c, "releases", assets, 2); err != nil || assets[0].Sha1 != sum {
+			t.Errorf("Nexus %d: Checksums() = %+v, %v", version, assets, err)
+		}
+		if err := c.Delete("releases", "com/example/app/2.0-SNAPSHOT"); err != nil {
+			t.Fatal(err)
+		}
+		if paths := server.Paths("releases"); len(paths) != 3 {
+			t.Errorf("Nexus %d: Delete() left %v", version, paths)
+		}
+		server.Close()
+	}
+}
+
+func TestFindAssets(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Version = 3
+	for _, p := range []string{
+		"com/example/app/1.0/app-1.0.jar",
+		"com/example/app/1.0/app-1.0.pom",
+		"com/example/app/1.1/app-1.1.jar",
+		"docs/index.html",
+		"docs/css/site.css",
+	} {
+		server.Put("releases", p, []byte(p))
+	}
+	// Sha1 and Delete must not list the whole repository
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == nexus3.AssetsPath && r.Method == "GET" {
+			t.Errorf("unexpected listing of the assets: %s", r.URL)
+		}
+		handler.ServeHTTP(w, r)
+	})
+	c, _ := New(3, Server{HostURL: server.URL, Username: server.Username, Password: server.Password})
+
+	for _, p := range []string{"com/example/app/1.0/app-1.0.pom", "docs/css/site.css"} {
+		if sum, err := c.Sha1("releases", p); err != nil || len(sum) != 40 {
+			t.Errorf("Sha1(%s) = %s, %v", p, sum, err)
+		}
+	}
+	if _, err := c.Sha1("releases", "docs/missing.html"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Sha1() of a missing file = %v, want ErrNotFound", err)
+	}
+	for _, p := range []string{"com/example/app/1.0", "docs"} {
+		if err := c.Delete("releases", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if paths := server.Paths("releases"); len(paths) != 1 || paths[0] != "com/example/app/1.1/app-1.1.jar" {
+		t.Errorf("Delete() left %v", paths)
+	}
+}
+
+// memoryCache is an ArtifactCache in memory
+type memoryCache struct {
+	files       map[string][]byte
+	resolutions map[string][]byte
+}
+
+func (c *memoryCache) Copy(sha1, filePath string) (bool, error) {
+	b, found := c.files[sha1]
+	if !found {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(filePath, b, 0644)
+}
+
+func (c *memoryCache) Put(sha1, filePath, name string) error {
+	b, err := ioutil.ReadFile(filePath)
+	c.files[sha1] = b
+	return err
+}
+
+func (c *memoryCache) PutResolution(key string, b []byte) error {
+	c.resolutions[key] = b
+	return nil
+}
+
+func (c *memoryCache) Resolution(key string) ([]byte, bool, error) {
+	b, found := c.resolutions[key]
+	return b, found, nil
+}
+
+func TestDownloadArtifact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { Cache, Offline = nil, false }()
+	Cache = &memoryCache{files: map[string][]byte{}, resolutions: map[string][]byte{}}
+
+	for _, version := range []int{2, 3} {
+		Offline = false
+		server := nexustest.NewServer()
+		server.Version = version
+		server.Put("maven-releases", "com/example/app/1.0/app-1.0.tar.gz", []byte("tar"))
+		c, _ := New(version, Server{HostURL: server.URL, Username: server.Username, Password: server.Password})
+		r := Request{
+			Coordinates: Coordinates{Group: "com.example", Artifact: "app", Version: "LATEST", Extension: "tar.gz"},
+			Repository:  "maven-releases",
+			Dir:         dir,
+		}
+		filePath, err := DownloadArtifact(c, r)
+		if err != nil || filepath.Base(filePath) != "app-1.0.tar.gz" {
+			t.Errorf("Nexus %d: DownloadArtifact() = %s, %v", version, filePath, err)
+		}
+		r.Sha1 = "0000000000000000000000000000000000000000"
+		if _, err := DownloadArtifact(c, r); err == nil {
+			t.Errorf("Nexus %d: DownloadArtifact() with a wrong sha1 should fail", version)
+		}
+		r.Sha1 = ""
+
+		// the cached resolution and file are used once the server is gone, under both versions
+		server.Close()
+		Offline = true
+		r.Filename = "app.tar.gz"
+		for _, v := range []string{"LATEST", "1.0"} {
+			r.Version = v
+			filePath, err = DownloadArtifact(c, r)
+			if b, _ := ioutil.ReadFile(filePath); err != nil || string(b) != "tar" {
+				t.Errorf("Nexus %d: offline DownloadArtifact(%s) = %s, %v", version, v, filePath, err)
+			}
+		}
+		r.Version = "2.0"
+		if _, err := DownloadArtifact(c, r); !errors.Is(err, ErrNotCached) {
+			t.Errorf("Nexus %d: offline DownloadArtifact(2.0) = %v, want ErrNotCached", version, err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package nexus2
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bzon/nexus-cli/maven"
+)
+
+const (
+	// ContentPath serves and stores the files of a repository
+	ContentPath = "/content/repositories/"
+	// RepositoriesPath lists, describes and deletes the content of a repository
+	RepositoriesPath = "/service/local/repositories/"
+	// MetadataPath rebuilds the Maven metadata of a repository directory
+	MetadataPath = "/service/local/metadata/repositories/"
+)
+
+// ErrContentNotFound is wrapped by the errors of the requests for a file or a directory that the repository does not have
+var ErrContentNotFound = errors.New("no such file in the repository")
+
+// ContentItem is an entry of a repository directory listing
+type ContentItem struct {
+	ResourceURI  string `json:"resourceURI"`
+	RelativePath string `json:"relativePath"`
+	Text         string `json:"text"`
+	Leaf         bool   `json:"leaf"`
+	LastModified string `json:"lastModified"`
+	SizeOnDisk   int64  `json:"sizeOnDisk"`
+}
+
+// PromotedFile is a file copied by PromoteComponent
+type PromotedFile struct {
+	Path string `json:"path" yaml:"path"`
+	Sha1 string `json:"sha1" yaml:"sha1"`
+	Size int64  `json:"size" yaml:"size"`
+}
+
+// sendContent sends an authenticated request and returns an error unless the response has the expected status
+func sendContent(aRequest ArtifactRequest, req *http.Request, expected ...int) (*http.Response, error) {
+	if err := authenticate(req, aRequest); err != nil {
+		return nil, err
+	}
+	resp, err := httpClient(aRequest).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("/%s %s %s", req.Method, resp.Status, req.URL)
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("Got %s while querying %s: %w", resp.Status, req.URL.String(), ErrContentNotFound)
+	}
+	return nil, fmt.Errorf("Got %s while querying %s", resp.Status, req.URL.String())
+}
+
+// ListContent lists a directory of a repository, path is relative to the repository root
+func ListContent(aRequest ArtifactRequest, repository, path string) ([]ContentItem, error) {
+	req, err := http.NewRequest("GET", aRequest.HostURL+RepositoriesPath+repository+"/content/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	resp, err := sendContent(aRequest, req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var listing struct {
+		Data []ContentItem `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, err
+	}
+	return listing.Data, nil
+}
+
+// ListFiles returns the files of a repository directory and its subdirectories, with their relative
+// path in RelativePath. Directories starting with a dot, such as .index and .meta, are internal to
+// Nexus and skipped.
+func ListFiles(aRequest ArtifactRequest, repository, dir string) ([]ContentItem, error) {
+	items, err := ListContent(aRequest, repository, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []ContentItem
+	for _, item := range items {
+		if strings.HasPrefix(item.Text, ".") {
+			continue
+		}
+		item.RelativePath = strings.Trim(item.RelativePath, "/")
+		if item.RelativePath == "" {
+			item.RelativePath = strings.TrimPrefix(dir+item.Text, "/")
+		}
+		if !item.Leaf {
+			sub, err := ListFiles(aRequest, repository, item.RelativePath+"/")
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, sub...)
+			continue
+		}
+		files = append(files, item)
+	}
+	return files, nil
+}
+
+// ContentSha1 returns the sha1 that Nexus stores for a file of a repository
+func ContentSha1(aRequest ArtifactRequest, repository, path string) (string, error) {
+	req, err := http.NewRequest("GET", aRequest.HostURL+RepositoriesPath+repository+"/content/"+strings.TrimPrefix(path, "/")+"?describe=info", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Accept", "application/json")
+	resp, err := sendContent(aRequest, req, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var info struct {
+		Data struct {
+			Sha1 string `json:"sha1"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	return info.Data.Sha1, nil
+}
+
+// GetContent downloads a file of a repository to filePath and returns its sha1
+func GetContent(aRequest ArtifactRequest, repository, path, filePath string) (string, error) {
+	req, err := http.NewRequest("GET", aRequest.HostURL+ContentPath+repository+"/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := sendContent(aRequest, req, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// PutContent uploads a file to a repository
+func PutContent(aRequest ArtifactRequest, repository, path, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", aRequest.HostURL+ContentPath+repository+"/"+strings.TrimPrefix(path, "/"), f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	resp, err := sendContent(aRequest, req, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DeleteContent deletes a file or a directory of a repository
+func DeleteContent(aRequest ArtifactRequest, repository, path string) error {
+	req, err := http.NewRequest("DELETE", aRequest.HostURL+RepositoriesPath+repository+"/content/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := sendContent(aRequest, req, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// RebuildMetadata asks Nexus to rebuild the maven-metadata.xml files of a directory of a repository and
+// of its subdirectories, which keep listing deleted files otherwise
+func RebuildMetadata(aRequest ArtifactRequest, repository, path string) error {
+	req, err := http.NewRequest("DELETE", aRequest.HostURL+MetadataPath+repository+"/content/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := sendContent(aRequest, req, http.StatusOK, http.StatusAccepted, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// ComponentPath returns the directory of a component: <group path>/<artifact>/<version>/
+func ComponentPath(group, artifact, version string) string {
+	return strings.Replace(group, ".", "/", -1) + "/" + artifact + "/" + version + "/"
+}
+
+// PromoteComponent copies every file of the <group>/<artifact>/<version> directory of aRequest from one
+// repository to another. Every file is downloaded and its sha1 compared with the one of the source,
+// uploaded, and its sha1 in the target compared again, .asc signatures included. Checksum files
+// are copied after the files they describe. maven-metadata.xml files are left to Nexus. With deleteSource, the source directory
+// is deleted once every file is verified.
+func PromoteComponent(aRequest ArtifactRequest, from, to string, deleteSource bool) ([]PromotedFile, error) {
+	dir := ComponentPath(aRequest.GroupID, aRequest.Artifact, aRequest.Version)
+	items, err := ListContent(aRequest, from, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files, checksums []string
+	for _, item := range items {
+		switch {
+		case !item.Leaf || strings.HasPrefix(item.Text, "maven-metadata.xml"):
+		case maven.Generated(item.Text):
+			checksums = append(checksums, item.Text)
+		default:
+			files = append(files, item.Text)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file in %s%s", from+"/", dir)
+	}
+
+	tmp, err := ioutil.TempDir("", "nexus-promote")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	var promoted []PromotedFile
+	for _, name := range append(files, checksums...) {
+		path := dir + name
+		log.Infof("Promoting %s from %s to %s", path, from, to)
+		filePath := filepath.Join(tmp, name)
+		localSha1, err := GetContent(aRequest, from, path, filePath)
+		if err != nil {
+			return promoted, err
+		}
+		if !maven.Generated(name) {
+			sourceSha1, err := ContentSha1(aRequest, from, path)
+			if err != nil {
+				return promoted, err
+			}
+			if sourceSha1 != localSha1 {
+				return promoted, fmt.Errorf("%s: downloaded sha1 %s, source sha1 %s", path, localSha1, sourceSha1)
+			}
+		}
+		if err := PutContent(aRequest, to, path, filePath); err != nil {
+			return promoted, err
+		}
+		if !maven.Generated(name) {
+			targetSha1, err := ContentSha1(aRequest, to, path)
+			if err != nil {
+				return promoted, err
+			}
+			if targetSha1 != localSha1 {
+				return promoted, fmt.Errorf("%s: uploaded sha1 %s, target sha1 %s", path, localSha1, targetSha1)
+			}
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return promoted, err
+		}
+		promoted = append(promoted, PromotedFile{Path: path, Sha1: localSha1, Size: info.Size()})
+	}
+
+	if deleteSource {
+		log.Infof("Deleting %s from %s", dir, from)
+		if err := DeleteContent(aRequest, from, dir); err != nil {
+			return promoted, err
+		}
+	}
+	return promoted, nil
+}
@@ -0,0 +1,64 @@
+// Copyright © 2018 bryansazon@hotmail.com
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package nexus2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// LoginPath is used to check credentials
+const LoginPath = "/service/local/authentication/login"
+
+// ErrUnauthorized is returned by Login when Nexus rejects the credentials
+var ErrUnauthorized = errors.New("the credentials were rejected by Nexus")
+
+// ErrNotFound is returned by Login when the server has no Nexus 2 login endpoint
+var ErrNotFound = errors.New("not a Nexus 2 server")
+
+// Login checks the credentials of an ArtifactRequest against the Nexus 2 server of its HostURL
+func Login(aRequest ArtifactRequest) error {
+	req, err := http.NewRequest("GET", aRequest.HostURL+LoginPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	if err := authenticate(req, aRequest); err != nil {
+		return err
+	}
+	resp, err := httpClient(aRequest).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	log.Debugf("/%s %s %s", req.Method, resp.Status, req.URL)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return fmt.Errorf("Got %s while querying %s", resp.Status, req.URL.String())
+	}
+}
@@ -29,8 +29,10 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"strings"
 
-	"github.com/fatih/color"
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/logging"
 )
 
 const (
@@ -40,6 +42,21 @@ const (
 	MavenResolvePath = "/service/local/artifact/maven/resolve"
 )
 
+// HTTPClient executes the requests of the ArtifactRequests that have no HTTPClient
+var HTTPClient = &http.Client{}
+
+// log receives the progress messages of the package
+var log, _ = logging.New(os.Stderr, logging.LevelInfo, "text")
+
+// SetLogger replaces the logger receiving the progress messages of the package.
+// A nil logger discards every message.
+func SetLogger(l logging.Logger) {
+	if l == nil {
+		l = logging.Discard
+	}
+	log = l
+}
+
 // ArtifactResolution contains all the data when querying
 // http://localhost:8081/nexus/nexus-restlet1x-plugin/default/docs/path__artifact_maven_resolve.htm
 // When media type is `application/json`
@@ -49,6 +66,7 @@ type ArtifactResolution struct {
 		GroupID             string `json:"groupId"`
 		ArtifactID          string `json:"artifactId"`
 		Version             string `json:"version"`
+		BaseVersion         string `json:"baseVersion"`
 		Extension           string `json:"extension"`
 		Snapshot            bool   `json:"snapshot"`
 		SnapshotBuildNumber int    `json:"snapshotBuildNumber"`
@@ -61,29 +79,37 @@ type ArtifactResolution struct {
 // ArtifactRequest holds the required fields for performing NewNexusQuery
 type ArtifactRequest struct {
 	Username, Password, HostURL, RepositoryID, GroupID, Version, Artifact, Packaging, DestinationDir string
+	// Classifier and Extension are optional and only sent when set
+	Classifier, Extension string
+	// Filename replaces the default <artifact>-<version>[-<classifier>].<extension> file name
+	Filename string
+	// Sha1 is the expected sha1 of the artifact, checked when set
+	Sha1 string
+	// Auth authenticates the requests. Basic authentication with Username and Password is used when nil.
+	Auth auth.Authenticator
+	// HTTPClient executes the requests, the package HTTPClient when nil
+	HTTPClient *http.Client
 }
 
-func handleError(err error) {
-	color.Set(color.FgRed)
-	if err != nil {
-		fmt.Printf("ERROR: %v\n", err)
-		os.Exit(1)
-	}
-	color.Unset()
+func setRepository(aRequest *ArtifactRequest) {
+	aRequest.RepositoryID = DefaultRepository(aRequest.Version)
 }
 
-func setRepository(aRequest *ArtifactRequest) {
-	if matched, _ := regexp.MatchString(".+-SNAPSHOT", aRequest.Version); matched {
-		aRequest.RepositoryID = "snapshots"
-	} else {
-		aRequest.RepositoryID = "releases"
+// DefaultRepository returns the repository used when an ArtifactRequest has no RepositoryID
+func DefaultRepository(version string) string {
+	if matched, _ := regexp.MatchString(".+-SNAPSHOT", version); matched {
+		return "snapshots"
 	}
+	return "releases"
 }
 
-// NewNexusQuery adds the required request Body parameters to the Query and then executes it
-func NewNexusQuery(req *http.Request, aRequest ArtifactRequest) *http.Response {
+// NewNexusQuery adds the required request Body parameters to the Query and then executes it.
+// An error is returned when the response status is not 200 OK.
+func NewNexusQuery(req *http.Request, aRequest ArtifactRequest) (*http.Response, error) {
 	// Set Authentication
-	req.SetBasicAuth(aRequest.Username, aRequest.Password)
+	if err := authenticate(req, aRequest); err != nil {
+		return nil, err
+	}
 
 	// Set the Repository to use
 	if aRequest.RepositoryID == "" {
@@ -97,84 +123,143 @@ func NewNexusQuery(req *http.Request, aRequest ArtifactRequest) *http.Response {
 	query.Add("v", aRequest.Version)
 	query.Add("a", aRequest.Artifact)
 	query.Add("p", aRequest.Packaging)
+	if aRequest.Classifier != "" {
+		query.Add("c", aRequest.Classifier)
+	}
+	if aRequest.Extension != "" {
+		query.Add("e", aRequest.Extension)
+	}
 	req.URL.RawQuery = query.Encode()
 
 	// Execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	handleError(err)
+	resp, err := httpClient(aRequest).Do(req)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		handleError(fmt.Errorf("Got %s while querying %s", resp.Status, req.URL.String()))
-	} else {
-		color.Set(color.FgGreen)
-		fmt.Println("/"+resp.Request.Method, resp.Status, resp.Request.URL)
-		color.Unset()
+		resp.Body.Close()
+		return nil, fmt.Errorf("Got %s while querying %s", resp.Status, req.URL.String())
 	}
+	log.Debugf("/%s %s %s", resp.Request.Method, resp.Status, resp.Request.URL)
+
+	return resp, nil
+}
 
-	return resp
+// authenticate adds the credentials of an ArtifactRequest to a request
+func authenticate(req *http.Request, aRequest ArtifactRequest) error {
+	var authenticator auth.Authenticator = auth.Basic{Username: aRequest.Username, Password: aRequest.Password}
+	if aRequest.Auth != nil {
+		authenticator = aRequest.Auth
+	}
+	return authenticator.Authenticate(req)
+}
+
+// httpClient returns the HTTP client of an ArtifactRequest
+func httpClient(aRequest ArtifactRequest) *http.Client {
+	if aRequest.HTTPClient != nil {
+		return aRequest.HTTPClient
+	}
+	return HTTPClient
 }
 
 // DownloadArtifact downloads artifacts from Nexus and validates it
 func DownloadArtifact(aRequest ArtifactRequest) (string, error) {
 	// Resolve and validate the artifact to download
 	aResolution, err := GetArtifactResolution(aRequest)
-	handleError(err)
+	if err != nil {
+		return "", err
+	}
+	return DownloadResolvedArtifact(aRequest, aResolution)
+}
+
+// DownloadResolvedArtifact downloads an artifact that was already resolved with GetArtifactResolution and validates it
+func DownloadResolvedArtifact(aRequest ArtifactRequest, aResolution *ArtifactResolution) (string, error) {
 	data := aResolution.Data
 
+	// Check the expected sha1 before downloading anything
+	if aRequest.Sha1 != "" && !strings.EqualFold(aRequest.Sha1, data.Sha1) {
+		return "", fmt.Errorf("Download error. Expected sha1 %s but Nexus resolved %s", aRequest.Sha1, data.Sha1)
+	}
+
 	// Declare the file path where to place the downloaded bytes
-	filePath := aRequest.DestinationDir + "/" + data.ArtifactID + "-" + data.Version + "." + data.Extension
+	fileName := aRequest.Filename
+	if fileName == "" {
+		fileName = data.ArtifactID + "-" + data.Version
+		if aRequest.Classifier != "" {
+			fileName += "-" + aRequest.Classifier
+		}
+		fileName += "." + data.Extension
+	}
+	filePath := aRequest.DestinationDir + "/" + fileName
 
 	// Download the resolved artifact
-	fmt.Printf("Downloading file %s:%s:%s:%s\n", data.GroupID, data.ArtifactID, data.Version, data.Extension)
-	req, err := http.NewRequest("GET", aRequest.HostURL+MavenRedirectPath, nil)
-	req.Header.Add("Accept", "application/xml")
-	handleError(err)
-	resp := NewNexusQuery(req, aRequest)
-	defer resp.Body.Close()
-
-	// Create the file
-	downloadedBytes, err := ioutil.ReadAll(resp.Body)
-	handleError(err)
-	cwd, _ := os.Getwd()
-	fmt.Println(cwd)
-	err = ioutil.WriteFile(filePath, downloadedBytes, 0644)
-	handleError(err)
+	log.Infof("Downloading file %s:%s:%s:%s", data.GroupID, data.ArtifactID, data.Version, data.Extension)
+	log.Debugf("Writing %s", filePath)
+	if err := fetchArtifact(aRequest, filePath); err != nil {
+		return "", err
+	}
 
 	// Get Remote file metadata SHA1
 	remoteSHA1 := data.Sha1
-	fmt.Println("Got remote sha1:", remoteSHA1)
+	log.Debugf("Got remote sha1: %s", remoteSHA1)
 
 	// Get Local downloaded file SHA1
 	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
 	hash := sha1.New()
-	_, err = hash.Write(b)
+	hash.Write(b)
 	hashInBytes := hash.Sum(nil)
 	localSHA1 := hex.EncodeToString(hashInBytes)
-	fmt.Printf("Got downloaded sha1: %s\n", localSHA1)
+	log.Debugf("Got downloaded sha1: %s", localSHA1)
 
 	// Compare SHA1s and return and error if it didn't match
 	if remoteSHA1 != localSHA1 {
+		os.Remove(filePath)
 		return "", fmt.Errorf("Download error. There is a mismatch in sha1sum")
 	}
 
 	// Print a successful message!
-	color.Set(color.FgGreen)
-	fmt.Printf("Successfully downloaded the file %s\n", filePath)
-	color.Unset()
+	log.Infof("Successfully downloaded the file %s", filePath)
 	return filePath, nil
 }
 
+// fetchArtifact writes the artifact of the redirect endpoint to filePath
+func fetchArtifact(aRequest ArtifactRequest, filePath string) error {
+	req, err := http.NewRequest("GET", aRequest.HostURL+MavenRedirectPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/xml")
+	resp, err := NewNexusQuery(req, aRequest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	downloadedBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, downloadedBytes, 0644)
+}
+
 // GetArtifactResolution resolves the ArtifactRequest and return the data needed for ArtifactResolution
 func GetArtifactResolution(aRequest ArtifactRequest) (*ArtifactResolution, error) {
-	fmt.Println("Resolving the artifact to download.")
+	log.Infof("Resolving the artifact %s:%s:%s:%s", aRequest.GroupID, aRequest.Artifact, aRequest.Version, aRequest.Packaging)
 	req, err := http.NewRequest("GET", aRequest.HostURL+MavenResolvePath, nil)
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Accept", "application/json")
-	handleError(err)
-	resp := NewNexusQuery(req, aRequest)
+	resp, err := NewNexusQuery(req, aRequest)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 	aResolution := new(ArtifactResolution)
-	decodedJSON := json.NewDecoder(resp.Body)
-	err = decodedJSON.Decode(aResolution)
-	handleError(err)
+	if err := json.NewDecoder(resp.Body).Decode(aResolution); err != nil {
+		return nil, err
+	}
 	return aResolution, nil
 }
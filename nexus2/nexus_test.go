@@ -2,21 +2,129 @@ package nexus2
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-)
 
-var aRequest = ArtifactRequest{"admin", "admin123", "http://localhost:8081/nexus", "releases", "com.example", "LATEST", "artifactA", "jar", "."}
+	"github.com/bzon/nexus-cli/nexustest"
+)
 
 func TestDownload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexus2-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Put("releases", "com/example/artifactA/1.0.0/artifactA-1.0.0.jar", []byte("old"))
+	server.Put("releases", "com/example/artifactA/1.0.10/artifactA-1.0.10.jar", []byte("latest"))
+	server.Put("releases", "com/example/artifactA/1.0.2/artifactA-1.0.2.jar", []byte("older"))
+
+	aRequest := ArtifactRequest{
+		Username:       server.Username,
+		Password:       server.Password,
+		HostURL:        server.URL,
+		RepositoryID:   "releases",
+		GroupID:        "com.example",
+		Version:        "LATEST",
+		Artifact:       "artifactA",
+		Packaging:      "jar",
+		DestinationDir: dir,
+	}
 	f, err := DownloadArtifact(aRequest)
 	if err != nil {
-		t.Errorf("DownloadArtifact(aRequest) %v", err)
+		t.Fatalf("DownloadArtifact(aRequest) %v", err)
+	}
+	if f != filepath.Join(dir, "artifactA-1.0.10.jar") {
+		t.Errorf("DownloadArtifact(aRequest) = %s, want %s", f, filepath.Join(dir, "artifactA-1.0.10.jar"))
+	}
+	if b, _ := ioutil.ReadFile(f); string(b) != "latest" {
+		t.Errorf("%s = %q, want %q", f, b, "latest")
+	}
+}
+
+func TestDownloadSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexus2-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Put("snapshots", "com/example/artifactB/1.1-SNAPSHOT/artifactB-1.1-20181012.101010-1.war", []byte("first"))
+	server.Put("snapshots", "com/example/artifactB/1.1-SNAPSHOT/artifactB-1.1-20181013.101010-2.war", []byte("second"))
+
+	aRequest := ArtifactRequest{
+		Username:       server.Username,
+		Password:       server.Password,
+		HostURL:        server.URL,
+		GroupID:        "com.example",
+		Version:        "1.1-SNAPSHOT",
+		Artifact:       "artifactB",
+		Packaging:      "war",
+		DestinationDir: dir,
 	}
-	err = os.Remove(f)
+	aResolution, err := GetArtifactResolution(aRequest)
 	if err != nil {
-		fmt.Printf("Failed deleting file %s\n", f)
-		os.Exit(1)
+		t.Fatal(err)
+	}
+	if aResolution.Data.Version != "1.1-20181013.101010-2" || aResolution.Data.SnapshotBuildNumber != 2 {
+		t.Errorf("resolved %+v, want build 2", aResolution.Data)
+	}
+	f, err := DownloadResolvedArtifact(aRequest, aResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(f); string(b) != "second" {
+		t.Errorf("%s = %q, want %q", f, b, "second")
+	}
+}
+
+func TestPromoteComponent(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	for p, content := range map[string]string{
+		"com/example/foo/1.0/foo-1.0.jar":      "foo",
+		"com/example/foo/1.0/foo-1.0.jar.sha1": "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		"com/example/foo/1.0/foo-1.0.jar.asc":  "signature",
+	} {
+		server.Put("staging", p, []byte(content))
+	}
+	// The signature is verified like any other file: a wrong sha1 in the source fails the promotion
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "staging/content/com/example/foo/1.0/foo-1.0.jar.asc") && r.URL.Query().Get("describe") == "info" {
+			fmt.Fprint(w, `{"data": {"sha1": "0000000000000000000000000000000000000000"}}`)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	aRequest := ArtifactRequest{HostURL: server.URL, Username: server.Username, Password: server.Password, GroupID: "com.example", Artifact: "foo", Version: "1.0"}
+	if _, err := PromoteComponent(aRequest, "staging", "releases", true); err == nil || !strings.Contains(err.Error(), "foo-1.0.jar.asc") {
+		t.Errorf("got %v, want a sha1 error on the signature", err)
+	}
+	if paths := server.Paths("staging"); len(paths) != 3 {
+		t.Errorf("the source was deleted after a failed promotion, staging has %v", paths)
+	}
+
+	server.Config.Handler = handler
+	promoted, err := PromoteComponent(aRequest, "staging", "releases", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(promoted) != 3 {
+		t.Errorf("promoted %+v", promoted)
+	}
+	if signature, _ := server.Get("releases", "com/example/foo/1.0/foo-1.0.jar.asc"); string(signature) != "signature" {
+		t.Errorf("released signature %q", signature)
+	}
+	if paths := server.Paths("staging"); len(paths) != 0 {
+		t.Errorf("the source was not deleted, staging has %v", paths)
 	}
-	fmt.Printf("File %s deleted\n", f)
 }
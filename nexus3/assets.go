@@ -0,0 +1,126 @@
+package nexus3
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AssetsPath is the Nexus 3 REST endpoint for listing and deleting assets
+	AssetsPath = "/service/rest/v1/assets"
+	// SearchAssetsPath is the Nexus 3 REST endpoint for searching assets
+	SearchAssetsPath = "/service/rest/v1/search/assets"
+)
+
+// Asset is a single file stored in a Nexus 3 repository
+type Asset struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	DownloadURL string `json:"downloadUrl"`
+	Repository  string `json:"repository"`
+	Format      string `json:"format"`
+	// FileSize and LastModified are not reported by older Nexus 3 versions
+	FileSize     int64  `json:"fileSize"`
+	LastModified string `json:"lastModified"`
+	Checksum     struct {
+		Sha1 string `json:"sha1"`
+		Md5  string `json:"md5"`
+	} `json:"checksum"`
+}
+
+// assetPage is one page of the paginated assets API response
+type assetPage struct {
+	Items             []Asset `json:"items"`
+	ContinuationToken string  `json:"continuationToken"`
+}
+
+// ListAssets returns every asset of the repository stored under directory.
+// An empty directory lists the whole repository.
+func (n *Client) ListAssets(directory string) ([]Asset, error) {
+	prefix := strings.Trim(directory, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	var assets []Asset
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("repository", n.Repository)
+		if token != "" {
+			query.Set("continuationToken", token)
+		}
+		req, err := http.NewRequest("GET", n.HostURL+AssetsPath+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := n.do(req, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var page assetPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Items {
+			a.Path = strings.TrimPrefix(a.Path, "/")
+			if strings.HasPrefix(a.Path, prefix) {
+				assets = append(assets, a)
+			}
+		}
+		if page.ContinuationToken == "" {
+			return assets, nil
+		}
+		token = page.ContinuationToken
+	}
+}
+
+// SearchAssets returns the assets of the repository matching the search query, for example the
+// name of a raw component, which is the path of its file, or the maven.* coordinates of a Maven asset.
+// A trailing * matches any suffix.
+func (n *Client) SearchAssets(query url.Values) ([]Asset, error) {
+	var assets []Asset
+	query.Set("repository", n.Repository)
+	for {
+		req, err := http.NewRequest("GET", n.HostURL+SearchAssetsPath+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := n.do(req, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var page assetPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Items {
+			a.Path = strings.TrimPrefix(a.Path, "/")
+			assets = append(assets, a)
+		}
+		if page.ContinuationToken == "" {
+			return assets, nil
+		}
+		query.Set("continuationToken", page.ContinuationToken)
+	}
+}
+
+// DeleteAsset removes an asset from the repository
+func (n *Client) DeleteAsset(a Asset) error {
+	req, err := http.NewRequest("DELETE", n.HostURL+AssetsPath+"/"+url.PathEscape(a.ID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := n.do(req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -1,6 +1,81 @@
+// Package nexus3 is a client of the Nexus 3 REST API. The site synchronisation, site publication
+// and raw directory download that were methods of Client are in package raw.
 package nexus3
 
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/logging"
+)
+
+// HTTPClient executes the requests of the Clients that have no HTTPClient
+var HTTPClient = &http.Client{}
+
+// ErrNotFound is wrapped by the errors of the requests for something that Nexus does not have
+var ErrNotFound = errors.New("not found")
+
+// log receives the progress messages of the package
+var log, _ = logging.New(os.Stderr, logging.LevelInfo, "text")
+
+// SetLogger replaces the logger receiving the progress messages of the package.
+// A nil logger discards every message.
+func SetLogger(l logging.Logger) {
+	if l == nil {
+		l = logging.Discard
+	}
+	log = l
+}
+
 // Nexus contains the fields requied for accessing a nexus server
 type Client struct {
 	Repository, HostURL, Username, Password string
+	// Auth authenticates the requests. Basic authentication with Username and Password is used when nil.
+	Auth auth.Authenticator
+	// HTTPClient executes the requests, the package HTTPClient when nil
+	HTTPClient *http.Client
+}
+
+// send executes an authenticated request
+func (n *Client) send(req *http.Request) (*http.Response, error) {
+	var authenticator auth.Authenticator = auth.Basic{Username: n.Username, Password: n.Password}
+	if n.Auth != nil {
+		authenticator = n.Auth
+	}
+	if err := authenticator.Authenticate(req); err != nil {
+		return nil, err
+	}
+	client := n.HTTPClient
+	if client == nil {
+		client = HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		log.Debugf("/%s %s %s", req.Method, resp.Status, req.URL)
+	}
+	return resp, err
+}
+
+// do executes an authenticated request and returns an error unless the response has the expected status
+func (n *Client) do(req *http.Request, expected int) (*http.Response, error) {
+	resp, err := n.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != expected {
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.String(), ErrNotFound)
+		}
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.String(), resp.Status, string(b))
+	}
+	return resp, nil
 }
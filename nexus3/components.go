@@ -0,0 +1,293 @@
+package nexus3
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bzon/nexus-cli/maven"
+)
+
+const (
+	// SearchPath is the Nexus 3 REST endpoint for searching components
+	SearchPath = "/service/rest/v1/search"
+	// ComponentsPath is the Nexus 3 REST endpoint for uploading and deleting components
+	ComponentsPath = "/service/rest/v1/components"
+)
+
+// Component is a versioned group of assets, for example the jar, pom and sources of a Maven artifact
+type Component struct {
+	ID         string  `json:"id"`
+	Repository string  `json:"repository"`
+	Format     string  `json:"format"`
+	Group      string  `json:"group"`
+	Name       string  `json:"name"`
+	Version    string  `json:"version"`
+	Assets     []Asset `json:"assets"`
+}
+
+// componentPage is one page of the paginated search API response
+type componentPage struct {
+	Items             []Component `json:"items"`
+	ContinuationToken string      `json:"continuationToken"`
+}
+
+// PromotedAsset is an asset copied by PromoteComponent
+type PromotedAsset struct {
+	Path string `json:"path" yaml:"path"`
+	Sha1 string `json:"sha1" yaml:"sha1"`
+	Size int64  `json:"size" yaml:"size"`
+}
+
+// SearchComponents returns the components of the repository matching the search query,
+// for example group, name and version
+func (n *Client) SearchComponents(query url.Values) ([]Component, error) {
+	var components []Component
+	query.Set("repository", n.Repository)
+	for {
+		req, err := http.NewRequest("GET", n.HostURL+SearchPath+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := n.do(req, http.StatusOK)
+		if err != nil {
+			return nil, err
+		}
+		var page componentPage
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, page.Items...)
+		if page.ContinuationToken == "" {
+			return components, nil
+		}
+		query.Set("continuationToken", page.ContinuationToken)
+	}
+}
+
+// FindComponent returns the single Maven component of the repository with a group, name and version
+func (n *Client) FindComponent(group, name, version string) (Component, error) {
+	components, err := n.SearchComponents(url.Values{"group": {group}, "name": {name}, "version": {version}})
+	if err != nil {
+		return Component{}, err
+	}
+	if len(components) != 1 {
+		return Component{}, fmt.Errorf("found %d components %s:%s:%s in %s, expected 1", len(components), group, name, version, n.Repository)
+	}
+	return components[0], nil
+}
+
+// DownloadAsset downloads an asset to filePath and returns its sha1
+func (n *Client) DownloadAsset(a Asset, filePath string) (string, error) {
+	req, err := http.NewRequest("GET", a.DownloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := n.do(req, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	f, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// MavenAsset is a file of a Maven component upload
+type MavenAsset struct {
+	File                  string
+	Classifier, Extension string
+}
+
+// UploadMavenComponent uploads the assets of a Maven component to the repository in a single request.
+// The pom is not generated, it should be one of the assets.
+func (n *Client) UploadMavenComponent(group, artifact, version string, assets []MavenAsset) error {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		err := writeMavenForm(form, group, artifact, version, assets)
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	query := url.Values{"repository": {n.Repository}}
+	req, err := http.NewRequest("POST", n.HostURL+ComponentsPath+"?"+query.Encode(), body)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := n.do(req, http.StatusNoContent)
+	if err != nil {
+		body.Close()
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func writeMavenForm(form *multipart.Writer, group, artifact, version string, assets []MavenAsset) error {
+	fields := [][2]string{
+		{"maven2.groupId", group},
+		{"maven2.artifactId", artifact},
+		{"maven2.version", version},
+		{"maven2.generate-pom", "false"},
+	}
+	for i, a := range assets {
+		prefix := fmt.Sprintf("maven2.asset%d", i+1)
+		fields = append(fields, [2]string{prefix + ".extension", a.Extension})
+		if a.Classifier != "" {
+			fields = append(fields, [2]string{prefix + ".classifier", a.Classifier})
+		}
+	}
+	for _, f := range fields {
+		if err := form.WriteField(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	for i, a := range assets {
+		part, err := form.CreateFormFile(fmt.Sprintf("maven2.asset%d", i+1), filepath.Base(a.File))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(a.File)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteComponent removes a component and all its assets
+func (n *Client) DeleteComponent(c Component) error {
+	req, err := http.NewRequest("DELETE", n.HostURL+ComponentsPath+"/"+url.PathEscape(c.ID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := n.do(req, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// MavenClassifierExtension returns the classifier and extension of a Maven asset path,
+// for example sources and jar for com/example/foo/1.0/foo-1.0-sources.jar
+func MavenClassifierExtension(assetPath, artifact, version string) (string, string) {
+	rest := strings.TrimPrefix(path.Base(assetPath), artifact+"-"+version)
+	classifier := ""
+	if strings.HasPrefix(rest, "-") {
+		i := strings.Index(rest, ".")
+		if i < 0 {
+			return rest[1:], ""
+		}
+		classifier, rest = rest[1:i], rest[i:]
+	}
+	return classifier, strings.TrimPrefix(rest, ".")
+}
+
+// PromoteComponent copies a Maven component and its assets from the client repository to
+// the target repository. Every asset is downloaded and its sha1 compared with the one of the
+// source, the assets are uploaded together and the sha1 of every target asset is compared again.
+// Checksum assets are generated by Nexus and not copied, while the .asc signatures are uploaded
+// with their <extension>.asc extension. With deleteSource, the source component
+// is deleted once every asset is verified. A -SNAPSHOT version is refused, as Nexus 3 keeps every
+// build of a snapshot as a component of its own timestamped version.
+func (n *Client) PromoteComponent(group, artifact, version, target string, deleteSource bool) ([]PromotedAsset, error) {
+	if strings.HasSuffix(version, "-SNAPSHOT") {
+		return nil, fmt.Errorf("cannot promote %s:%s:%s, Nexus 3 keeps every snapshot build as a component of its own: promote a release version or a timestamped snapshot version", group, artifact, version)
+	}
+	source, err := n.FindComponent(group, artifact, version)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempDir("", "nexus-promote")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var uploads []MavenAsset
+	var promoted []PromotedAsset
+	for _, a := range source.Assets {
+		name := path.Base(a.Path)
+		if maven.Generated(name) {
+			continue
+		}
+		log.Infof("Downloading %s from %s", a.Path, n.Repository)
+		filePath := filepath.Join(tmp, name)
+		sum, err := n.DownloadAsset(a, filePath)
+		if err != nil {
+			return nil, err
+		}
+		if a.Checksum.Sha1 != "" && sum != a.Checksum.Sha1 {
+			return nil, fmt.Errorf("%s: downloaded sha1 %s, source sha1 %s", a.Path, sum, a.Checksum.Sha1)
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, err
+		}
+		classifier, extension := MavenClassifierExtension(a.Path, artifact, version)
+		uploads = append(uploads, MavenAsset{File: filePath, Classifier: classifier, Extension: extension})
+		promoted = append(promoted, PromotedAsset{Path: strings.TrimPrefix(a.Path, "/"), Sha1: sum, Size: info.Size()})
+	}
+	if len(uploads) == 0 {
+		return nil, fmt.Errorf("component %s:%s:%s in %s has no asset", group, artifact, version, n.Repository)
+	}
+
+	log.Infof("Uploading %d assets to %s", len(uploads), target)
+	to := *n
+	to.Repository = target
+	if err := to.UploadMavenComponent(group, artifact, version, uploads); err != nil {
+		return nil, err
+	}
+	copied, err := to.FindComponent(group, artifact, version)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]string{}
+	for _, a := range copied.Assets {
+		sums[strings.TrimPrefix(a.Path, "/")] = a.Checksum.Sha1
+	}
+	for _, p := range promoted {
+		if sums[p.Path] != p.Sha1 {
+			return promoted, fmt.Errorf("%s: uploaded sha1 %s, target sha1 %q", p.Path, p.Sha1, sums[p.Path])
+		}
+	}
+
+	if deleteSource {
+		log.Infof("Deleting %s:%s:%s from %s", group, artifact, version, n.Repository)
+		if err := n.DeleteComponent(source); err != nil {
+			return promoted, err
+		}
+	}
+	return promoted, nil
+}
@@ -0,0 +1,59 @@
+package nexus3
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+func TestPromoteComponent(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	for p, content := range map[string]string{
+		"com/example/foo/1.0/foo-1.0.jar":         "foo",
+		"com/example/foo/1.0/foo-1.0.jar.sha1":    "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+		"com/example/foo/1.0/foo-1.0.jar.asc":     "signature",
+		"com/example/foo/1.0/foo-1.0.jar.asc.md5": "c0ebf8a1ba8f3a4fd5fb2eb4fc5f6d4e",
+		"com/example/foo/1.0/foo-1.0-sources.jar": "bar",
+		"com/example/foo/1.0/maven-metadata.xml":  "<metadata/>",
+		"com/example/foo/1.1/foo-1.1.jar":         "other",
+	} {
+		server.Put("staging", p, []byte(content))
+	}
+	server.Put("releases", "README", []byte("releases"))
+
+	client := Client{HostURL: server.URL, Username: server.Username, Password: server.Password, Repository: "staging"}
+	promoted, err := client.PromoteComponent("com.example", "foo", "1.0", "releases", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "README,com/example/foo/1.0/foo-1.0-sources.jar,com/example/foo/1.0/foo-1.0.jar,com/example/foo/1.0/foo-1.0.jar.asc"
+	if paths := server.Paths("releases"); len(promoted) != 3 || strings.Join(paths, ",") != want {
+		t.Errorf("promoted %+v, released %v", promoted, paths)
+	}
+	if signature, _ := server.Get("releases", "com/example/foo/1.0/foo-1.0.jar.asc"); string(signature) != "signature" {
+		t.Errorf("released signature %q", signature)
+	}
+	if paths := server.Paths("staging"); strings.Join(paths, ",") != "com/example/foo/1.1/foo-1.1.jar" {
+		t.Errorf("the source component was not deleted, staging has %v", paths)
+	}
+
+	if _, err := client.PromoteComponent("com.example", "foo", "1.1-SNAPSHOT", "releases", false); err == nil || !strings.Contains(err.Error(), "snapshot") {
+		t.Errorf("got %v, want an error for the -SNAPSHOT version", err)
+	}
+}
+
+func TestMavenClassifierExtension(t *testing.T) {
+	for path, want := range map[string][2]string{
+		"com/example/foo/1.0/foo-1.0.jar":         {"", "jar"},
+		"com/example/foo/1.0/foo-1.0-sources.jar": {"sources", "jar"},
+		"com/example/foo/1.0/foo-1.0.tar.gz":      {"", "tar.gz"},
+		"com/example/foo/1.0/foo-1.0-bin.tar.gz":  {"bin", "tar.gz"},
+	} {
+		classifier, extension := MavenClassifierExtension(path, "foo", "1.0")
+		if classifier != want[0] || extension != want[1] {
+			t.Errorf("%s: got %s, %s, want %v", path, classifier, extension, want)
+		}
+	}
+}
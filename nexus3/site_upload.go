@@ -2,9 +2,11 @@ package nexus3
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 )
 
 // SiteComponent contains the fields that will be passed as a parameter for NexusUpload
@@ -19,13 +21,20 @@ func (n *Client) SiteFileUpload(c SiteComponent) (string, error) {
 		return "", err
 	}
 	defer file.Close()
-	uri := n.GetRepoURL() + "/" + c.Directory + "/" + c.Filename
-	req, err := http.NewRequest("PUT", uri, file)
+	return n.siteUpload(c.Directory, c.Filename, file)
+}
+
+// siteUpload uploads the content read from body as directory/filename and returns the uploaded file url
+func (n *Client) siteUpload(directory, filename string, body io.Reader) (string, error) {
+	uri := n.GetRepoURL() + "/" + filename
+	if dir := strings.Trim(directory, "/"); dir != "" {
+		uri = n.GetRepoURL() + "/" + dir + "/" + filename
+	}
+	req, err := http.NewRequest("PUT", uri, body)
 	if err != nil {
 		return "", err
 	}
-	req.SetBasicAuth(n.Username, n.Password)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := n.send(req)
 	if err != nil {
 		return "", err
 	}
@@ -40,6 +49,7 @@ func (n *Client) SiteFileUpload(c SiteComponent) (string, error) {
 	return uri, nil
 }
 
+// GetRepoURL returns the url of the repository, below which its files are read and written
 func (n *Client) GetRepoURL() string {
 	return n.HostURL + "/repository/" + n.Repository
 }
@@ -1,20 +1,23 @@
 package nexus3
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
-)
 
-var nexus = Client{
-	HostURL:    "http://localhost:8081",
-	Username:   "admin",
-	Password:   "admin123",
-	Repository: "site",
-}
+	"github.com/bzon/nexus-cli/nexustest"
+)
 
 func TestSiteFileUpload(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	nexus := Client{
+		HostURL:    server.URL,
+		Username:   server.Username,
+		Password:   server.Password,
+		Repository: "site",
+	}
+
 	ioutil.WriteFile("file.txt", []byte("foo"), 0644)
 	defer os.Remove("file.txt")
 	var testComponent = SiteComponent{
@@ -26,5 +29,15 @@ func TestSiteFileUpload(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fmt.Println("nexus url:", uri)
+	if want := server.URL + "/repository/site/go_upload_test/file.txt"; uri != want {
+		t.Errorf("SiteFileUpload() = %s, want %s", uri, want)
+	}
+	if b, _ := server.Get("site", "go_upload_test/file.txt"); string(b) != "foo" {
+		t.Errorf("uploaded %q, want %q", b, "foo")
+	}
+
+	nexus.Password = "wrong"
+	if _, err := nexus.SiteFileUpload(testComponent); err == nil {
+		t.Error("SiteFileUpload() with a wrong password should fail")
+	}
 }
@@ -0,0 +1,36 @@
+package nexus3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusCheckPath is the system status endpoint, used to check credentials
+const StatusCheckPath = "/service/rest/v1/status/check"
+
+// ErrUnauthorized is returned by CheckCredentials when Nexus rejects the credentials
+var ErrUnauthorized = errors.New("the credentials were rejected by Nexus")
+
+// CheckCredentials sends an authenticated request to the status endpoint.
+// Nexus answers 401 to any request with wrong credentials. A 403 means that the
+// credentials are valid but the user may not read the system status.
+func (n *Client) CheckCredentials() error {
+	req, err := http.NewRequest("GET", n.HostURL+StatusCheckPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := n.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusForbidden:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	default:
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.String(), resp.Status)
+	}
+}
@@ -0,0 +1,339 @@
+package nexustest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bzon/nexus-cli/maven"
+)
+
+// Nexus 2 endpoints
+const (
+	resolvePath      = "/service/local/artifact/maven/resolve"
+	redirectPath     = "/service/local/artifact/maven/redirect"
+	loginPath        = "/service/local/authentication/login"
+	repositoriesPath = "/service/local/repositories/"
+	metadataPath     = "/service/local/metadata/repositories/"
+	contentPath      = "/content/repositories/"
+)
+
+func (s *Server) serveNexus2(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == resolvePath:
+		s.resolve(w, r)
+	case r.URL.Path == redirectPath:
+		s.redirect(w, r)
+	case r.URL.Path == loginPath:
+		if _, _, ok := r.BasicAuth(); !ok && s.Username != "" {
+			http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"clientPermissions": map[string]interface{}{}}})
+	case strings.HasPrefix(r.URL.Path, repositoriesPath):
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, repositoriesPath), "/content", 2)
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		s.repositoryContent(w, r, parts[0], strings.TrimPrefix(parts[1], "/"))
+	case strings.HasPrefix(r.URL.Path, metadataPath) && r.Method == "DELETE":
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, metadataPath), "/content", 2)
+		if len(parts) != 2 || s.repos[parts[0]] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.rebuildMetadata(parts[0], strings.Trim(parts[1], "/"))
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(r.URL.Path, contentPath):
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, contentPath), "/", 2)
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		s.content(w, r, parts[0], parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// content serves and stores the files of /content/repositories/<repository>/<path>
+func (s *Server) content(w http.ResponseWriter, r *http.Request, repository, p string) {
+	switch r.Method {
+	case "GET", "HEAD":
+		f, found := s.repos[repository][p]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", f.modified.Format(http.TimeFormat))
+		w.Write(f.data)
+	case "PUT":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.put(repository, p, data)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// contentItem is an entry of a Nexus 2 directory listing
+type contentItem struct {
+	ResourceURI  string `json:"resourceURI"`
+	RelativePath string `json:"relativePath"`
+	Text         string `json:"text"`
+	Leaf         bool   `json:"leaf"`
+	LastModified string `json:"lastModified"`
+	SizeOnDisk   int64  `json:"sizeOnDisk"`
+}
+
+// repositoryContent lists a directory, describes a file with ?describe=info or deletes a path
+// of /service/local/repositories/<repository>/content/<path>
+func (s *Server) repositoryContent(w http.ResponseWriter, r *http.Request, repository, p string) {
+	if _, found := s.repos[repository]; !found {
+		http.NotFound(w, r)
+		return
+	}
+	dir := strings.Trim(p, "/")
+	switch r.Method {
+	case "DELETE":
+		if !s.delete(repository, dir) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "GET":
+		if f, found := s.repos[repository][dir]; found && r.URL.Query().Get("describe") == "info" {
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{
+				"repositoryPath": "/" + dir,
+				"size":           len(f.data),
+				"sha1Hash":       f.sha1(),
+				"sha1":           f.sha1(),
+				"md5Hash":        f.md5(),
+			}})
+			return
+		}
+		items := s.list(repository, dir)
+		if items == nil && dir != "" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"data": items})
+	default:
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// list returns the files and directories directly below a directory
+func (s *Server) list(repository, dir string) []contentItem {
+	items := []contentItem{}
+	seen := map[string]bool{}
+	for _, p := range s.paths(repository, dir) {
+		rest := strings.TrimPrefix(p, dir+"/")
+		if dir == "" {
+			rest = p
+		}
+		name := strings.SplitN(rest, "/", 2)[0]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		relative := "/" + strings.TrimPrefix(dir+"/"+name, "/")
+		item := contentItem{
+			ResourceURI:  s.URL + repositoriesPath + repository + "/content" + relative,
+			RelativePath: relative,
+			Text:         name,
+			Leaf:         name == rest,
+		}
+		if item.Leaf {
+			f := s.repos[repository][p]
+			item.SizeOnDisk = int64(len(f.data))
+			item.LastModified = f.modified.Format("2006-01-02 15:04:05.0 MST")
+		} else {
+			item.RelativePath += "/"
+			item.ResourceURI += "/"
+			item.SizeOnDisk = -1
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+// rebuildMetadata rewrites the maven-metadata.xml of the -SNAPSHOT directories below a directory with
+// their remaining builds, and deletes it from the directories left without builds
+func (s *Server) rebuildMetadata(repository, dir string) {
+	builds := map[string][]string{}
+	for _, p := range s.paths(repository, dir) {
+		versionDir := path.Dir(p)
+		baseVersion := path.Base(versionDir)
+		if !strings.HasSuffix(baseVersion, "-SNAPSHOT") {
+			continue
+		}
+		if _, found := builds[versionDir]; !found {
+			builds[versionDir] = nil
+		}
+		prefix := path.Base(path.Dir(versionDir)) + "-" + strings.TrimSuffix(baseVersion, "SNAPSHOT")
+		if stamp := timestamped.FindString(strings.TrimPrefix(path.Base(p), prefix)); strings.HasPrefix(path.Base(p), prefix) && stamp != "" {
+			version := strings.TrimSuffix(baseVersion, "SNAPSHOT") + stamp
+			if n := len(builds[versionDir]); n == 0 || builds[versionDir][n-1] != version {
+				builds[versionDir] = append(builds[versionDir], version)
+			}
+		}
+	}
+	for versionDir, versions := range builds {
+		metadata := versionDir + "/maven-metadata.xml"
+		if len(versions) == 0 {
+			delete(s.repos[repository], metadata)
+			continue
+		}
+		parts := strings.Split(versionDir, "/")
+		var b strings.Builder
+		b.WriteString(xml.Header + "<metadata>\n")
+		fmt.Fprintf(&b, "  <groupId>%s</groupId>\n", strings.Join(parts[:len(parts)-2], "."))
+		fmt.Fprintf(&b, "  <artifactId>%s</artifactId>\n", parts[len(parts)-2])
+		fmt.Fprintf(&b, "  <version>%s</version>\n  <versioning>\n    <snapshotVersions>\n", parts[len(parts)-1])
+		for _, v := range versions {
+			fmt.Fprintf(&b, "      <snapshotVersion><value>%s</value></snapshotVersion>\n", v)
+		}
+		b.WriteString("    </snapshotVersions>\n  </versioning>\n</metadata>\n")
+		s.put(repository, metadata, []byte(b.String()))
+	}
+}
+
+// resolution is the data of a Nexus 2 maven resolve response
+type resolution struct {
+	PresentLocally      bool   `json:"presentLocally"`
+	GroupID             string `json:"groupId"`
+	ArtifactID          string `json:"artifactId"`
+	Version             string `json:"version"`
+	BaseVersion         string `json:"baseVersion"`
+	Classifier          string `json:"classifier,omitempty"`
+	Extension           string `json:"extension"`
+	Snapshot            bool   `json:"snapshot"`
+	SnapshotBuildNumber int    `json:"snapshotBuildNumber"`
+	SnapshotTimeStamp   int64  `json:"snapshotTimeStamp"`
+	Sha1                string `json:"sha1"`
+	RepositoryPath      string `json:"repositoryPath"`
+}
+
+// resolve answers the maven resolve endpoint
+func (s *Server) resolve(w http.ResponseWriter, r *http.Request) {
+	res, found := s.resolveArtifact(r)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"data": res})
+}
+
+// redirect answers the maven redirect endpoint with a redirect to the content of the resolved file
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request) {
+	res, found := s.resolveArtifact(r)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	http.Redirect(w, r, contentPath+r.URL.Query().Get("r")+res.RepositoryPath, http.StatusTemporaryRedirect)
+}
+
+// timestamped matches the <timestamp>-<build number> of a snapshot file name
+var timestamped = regexp.MustCompile(`^(\d{8}\.\d{6})-(\d+)`)
+
+// resolveArtifact resolves the r, g, a, v, p, c and e parameters to a stored file.
+// LATEST and RELEASE resolve to the highest version, X-SNAPSHOT to its newest timestamped build.
+func (s *Server) resolveArtifact(r *http.Request) (resolution, bool) {
+	q := r.URL.Query()
+	repository, group, artifact, version := q.Get("r"), q.Get("g"), q.Get("a"), q.Get("v")
+	classifier, extension := q.Get("c"), q.Get("e")
+	if extension == "" {
+		extension = q.Get("p")
+	}
+	artifactDir := strings.Replace(group, ".", "/", -1) + "/" + artifact
+	if version == "LATEST" || version == "RELEASE" {
+		versions := s.versions(repository, artifactDir, version == "RELEASE")
+		if len(versions) == 0 {
+			return resolution{}, false
+		}
+		version = versions[len(versions)-1]
+	}
+	suffix := "." + extension
+	if classifier != "" {
+		suffix = "-" + classifier + suffix
+	}
+	res := resolution{
+		PresentLocally: true,
+		GroupID:        group,
+		ArtifactID:     artifact,
+		Version:        version,
+		BaseVersion:    version,
+		Classifier:     classifier,
+		Extension:      extension,
+	}
+	dir := artifactDir + "/" + version
+	name := artifact + "-" + version + suffix
+	if strings.HasSuffix(version, "-SNAPSHOT") {
+		res.Snapshot = true
+		prefix := artifact + "-" + strings.TrimSuffix(version, "SNAPSHOT")
+		var builds []string
+		for _, p := range s.paths(repository, dir) {
+			base := path.Base(p)
+			if strings.HasPrefix(base, prefix) && strings.HasSuffix(base, suffix) &&
+				timestamped.MatchString(strings.TrimSuffix(strings.TrimPrefix(base, prefix), suffix)) {
+				builds = append(builds, base)
+			}
+		}
+		if len(builds) > 0 {
+			sort.Strings(builds)
+			name = builds[len(builds)-1]
+			m := timestamped.FindStringSubmatch(strings.TrimPrefix(name, prefix))
+			res.Version = strings.TrimSuffix(version, "SNAPSHOT") + m[0]
+			res.SnapshotBuildNumber, _ = strconv.Atoi(m[2])
+			if t, err := time.Parse("20060102.150405", m[1]); err == nil {
+				res.SnapshotTimeStamp = t.UnixNano() / int64(time.Millisecond)
+			}
+		}
+	}
+	f, found := s.repos[repository][dir+"/"+name]
+	if !found {
+		return resolution{}, false
+	}
+	res.Sha1 = f.sha1()
+	res.RepositoryPath = "/" + dir + "/" + name
+	return res, true
+}
+
+// versions returns the version directories of an artifact, lowest first
+func (s *Server) versions(repository, artifactDir string, releases bool) []string {
+	seen := map[string]bool{}
+	var versions []string
+	for _, p := range s.paths(repository, artifactDir) {
+		parts := strings.Split(strings.TrimPrefix(p, artifactDir+"/"), "/")
+		if len(parts) != 2 || seen[parts[0]] || (releases && strings.HasSuffix(parts[0], "-SNAPSHOT")) {
+			continue
+		}
+		seen[parts[0]] = true
+		versions = append(versions, parts[0])
+	}
+	sort.Slice(versions, func(i, j int) bool { return maven.CompareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,347 @@
+package nexustest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nexus 3 endpoints
+const (
+	assetsPath     = "/service/rest/v1/assets"
+	componentsPath = "/service/rest/v1/components"
+	searchPath     = "/service/rest/v1/search"
+	searchAssets   = "/service/rest/v1/search/assets"
+	statusPath     = "/service/rest/v1/status/check"
+	repositoryPath = "/repository/"
+)
+
+// pageSize is the number of items of a page of the assets and search endpoints
+const pageSize = 10
+
+func (s *Server) serveNexus3(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == assetsPath && r.Method == "GET":
+		s.listAssets(w, r)
+	case strings.HasPrefix(r.URL.Path, assetsPath+"/") && r.Method == "DELETE":
+		repository, p, ok := parseID(strings.TrimPrefix(r.URL.Path, assetsPath+"/"))
+		if !ok || s.repos[repository][p] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.delete(repository, p)
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == searchPath && r.Method == "GET":
+		s.search(w, r)
+	case r.URL.Path == searchAssets && r.Method == "GET":
+		s.searchAssets(w, r)
+	case r.URL.Path == componentsPath && r.Method == "POST":
+		s.uploadComponent(w, r)
+	case strings.HasPrefix(r.URL.Path, componentsPath+"/") && r.Method == "DELETE":
+		repository, p, ok := parseID(strings.TrimPrefix(r.URL.Path, componentsPath+"/"))
+		if !ok || !s.deleteComponent(repository, p) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == statusPath:
+		if _, _, ok := r.BasicAuth(); !ok && s.Username != "" {
+			http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case strings.HasPrefix(r.URL.Path, repositoryPath):
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, repositoryPath), "/", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		s.repository(w, r, parts[0], parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// repository serves, stores and deletes the files of /repository/<repository>/<path>
+func (s *Server) repository(w http.ResponseWriter, r *http.Request, repository, p string) {
+	switch r.Method {
+	case "GET", "HEAD":
+		f, found := s.repos[repository][p]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", f.modified.Format(http.TimeFormat))
+		w.Write(f.data)
+	case "PUT":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.put(repository, p, data)
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if s.repos[repository][p] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.delete(repository, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// asset is an item of the Nexus 3 assets API
+type asset struct {
+	ID           string            `json:"id"`
+	Path         string            `json:"path"`
+	DownloadURL  string            `json:"downloadUrl"`
+	Repository   string            `json:"repository"`
+	Format       string            `json:"format"`
+	FileSize     int64             `json:"fileSize"`
+	Checksum     map[string]string `json:"checksum"`
+	LastModified string            `json:"lastModified"`
+}
+
+func (s *Server) asset(repository, p string) asset {
+	f := s.repos[repository][p]
+	return asset{
+		ID:           id(repository, p),
+		Path:         p,
+		DownloadURL:  s.URL + repositoryPath + repository + "/" + p,
+		Repository:   repository,
+		Format:       "maven2",
+		FileSize:     int64(len(f.data)),
+		Checksum:     map[string]string{"sha1": f.sha1(), "md5": f.md5()},
+		LastModified: f.modified.Format(time.RFC3339),
+	}
+}
+
+// listAssets answers a page of the assets of a repository
+func (s *Server) listAssets(w http.ResponseWriter, r *http.Request) {
+	repository := r.URL.Query().Get("repository")
+	if _, found := s.repos[repository]; !found {
+		http.NotFound(w, r)
+		return
+	}
+	paths := s.paths(repository, "")
+	items := []asset{}
+	page, next := paginate(len(paths), r.URL.Query().Get("continuationToken"))
+	for _, i := range page {
+		items = append(items, s.asset(repository, paths[i]))
+	}
+	writeJSON(w, map[string]interface{}{"items": items, "continuationToken": next})
+}
+
+// component is an item of the Nexus 3 search API
+type component struct {
+	ID         string  `json:"id"`
+	Repository string  `json:"repository"`
+	Format     string  `json:"format"`
+	Group      string  `json:"group"`
+	Name       string  `json:"name"`
+	Version    string  `json:"version"`
+	Assets     []asset `json:"assets"`
+}
+
+// components groups the files of a repository in the Maven layout into components by their version
+// directory. Snapshot directories hold one component per timestamped build.
+func (s *Server) components(repository string) []component {
+	byKey := map[string]*component{}
+	var keys []string
+	for _, p := range s.paths(repository, "") {
+		parts := strings.Split(p, "/")
+		if len(parts) < 4 {
+			continue
+		}
+		name, baseVersion := parts[len(parts)-3], parts[len(parts)-2]
+		version := baseVersion
+		if strings.HasSuffix(baseVersion, "-SNAPSHOT") {
+			prefix := name + "-" + strings.TrimSuffix(baseVersion, "SNAPSHOT")
+			stamp := timestamped.FindString(strings.TrimPrefix(path.Base(p), prefix))
+			if !strings.HasPrefix(path.Base(p), prefix) || stamp == "" {
+				continue
+			}
+			version = strings.TrimSuffix(baseVersion, "SNAPSHOT") + stamp
+		}
+		dir := path.Dir(p)
+		key := dir + "/" + version
+		c, found := byKey[key]
+		if !found {
+			c = &component{
+				ID:         id(repository, dir),
+				Repository: repository,
+				Format:     "maven2",
+				Group:      strings.Join(parts[:len(parts)-3], "."),
+				Name:       name,
+				Version:    version,
+			}
+			if version != baseVersion {
+				c.ID = id(repository, dir+"/"+name+"-"+version)
+			}
+			byKey[key] = c
+			keys = append(keys, key)
+		}
+		c.Assets = append(c.Assets, s.asset(repository, p))
+	}
+	list := make([]component, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, *byKey[key])
+	}
+	return list
+}
+
+// search answers a page of the components of a repository matching the group, name and version parameters
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var matches []component
+	var repositories []string
+	if q.Get("repository") != "" {
+		repositories = []string{q.Get("repository")}
+	} else {
+		for repository := range s.repos {
+			repositories = append(repositories, repository)
+		}
+		sort.Strings(repositories)
+	}
+	for _, repository := range repositories {
+		for _, c := range s.components(repository) {
+			if (q.Get("group") == "" || q.Get("group") == c.Group) && (q.Get("name") == "" || q.Get("name") == c.Name) &&
+				(q.Get("version") == "" || q.Get("version") == c.Version) {
+				matches = append(matches, c)
+			}
+		}
+	}
+	items := []component{}
+	page, next := paginate(len(matches), q.Get("continuationToken"))
+	for _, i := range page {
+		items = append(items, matches[i])
+	}
+	writeJSON(w, map[string]interface{}{"items": items, "continuationToken": next})
+}
+
+// searchAssets answers a page of the assets of a repository matching the name, maven.groupId,
+// maven.artifactId and maven.baseVersion parameters. A file named <artifact>-* in a version directory
+// belongs to a Maven component named after its artifact, any other file to a raw component named
+// after its path. A name ending with * matches any suffix.
+func (s *Server) searchAssets(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	repository := q.Get("repository")
+	matchName := func(name string) bool {
+		want := q.Get("name")
+		if strings.HasSuffix(want, "*") {
+			return strings.HasPrefix(name, strings.TrimSuffix(want, "*"))
+		}
+		return want == "" || want == name
+	}
+	var matches []asset
+	for _, p := range s.paths(repository, "") {
+		parts := strings.Split(p, "/")
+		n := len(parts)
+		var group, artifact, baseVersion string
+		name := p
+		if n >= 4 && strings.HasPrefix(parts[n-1], parts[n-3]+"-") {
+			group, artifact, baseVersion = strings.Join(parts[:n-3], "."), parts[n-3], parts[n-2]
+			name = artifact
+		}
+		if matchName(name) && (q.Get("maven.groupId") == "" || q.Get("maven.groupId") == group) &&
+			(q.Get("maven.artifactId") == "" || q.Get("maven.artifactId") == artifact) &&
+			(q.Get("maven.baseVersion") == "" || q.Get("maven.baseVersion") == baseVersion) {
+			matches = append(matches, s.asset(repository, p))
+		}
+	}
+	items := []asset{}
+	page, next := paginate(len(matches), q.Get("continuationToken"))
+	for _, i := range page {
+		items = append(items, matches[i])
+	}
+	writeJSON(w, map[string]interface{}{"items": items, "continuationToken": next})
+}
+
+// deleteComponent deletes the files of a component id path: a version directory,
+// or <version directory>/<artifact>-<timestamped version> for a snapshot build
+func (s *Server) deleteComponent(repository, p string) bool {
+	if s.delete(repository, p) {
+		return true
+	}
+	deleted := false
+	for _, f := range s.paths(repository, path.Dir(p)) {
+		if path.Dir(f) == path.Dir(p) && (strings.HasPrefix(f, p+".") || strings.HasPrefix(f, p+"-")) {
+			delete(s.repos[repository], f)
+			deleted = true
+		}
+	}
+	return deleted
+}
+
+// paginate returns the indexes of the page of a continuation token and the token of the next page
+func paginate(total int, token string) ([]int, interface{}) {
+	start, _ := strconv.Atoi(token)
+	var page []int
+	for i := start; i < total && i < start+pageSize; i++ {
+		page = append(page, i)
+	}
+	if start+pageSize < total {
+		return page, strconv.Itoa(start + pageSize)
+	}
+	return page, nil
+}
+
+// uploadComponent stores the assets of a maven2 multipart component upload
+func (s *Server) uploadComponent(w http.ResponseWriter, r *http.Request) {
+	repository := r.URL.Query().Get("repository")
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fields := map[string]string{}
+	files := map[string][]byte{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FileName() != "" {
+			files[part.FormName()] = data
+		} else {
+			fields[part.FormName()] = string(data)
+		}
+	}
+	group, artifact, version := fields["maven2.groupId"], fields["maven2.artifactId"], fields["maven2.version"]
+	if group == "" || artifact == "" || version == "" || len(files) == 0 {
+		http.Error(w, "maven2.groupId, maven2.artifactId, maven2.version and an asset are required", http.StatusBadRequest)
+		return
+	}
+	dir := strings.Replace(group, ".", "/", -1) + "/" + artifact + "/" + version + "/"
+	for i := 1; ; i++ {
+		asset := fmt.Sprintf("maven2.asset%d", i)
+		data, found := files[asset]
+		if !found {
+			break
+		}
+		name := artifact + "-" + version
+		if classifier := fields[asset+".classifier"]; classifier != "" {
+			name += "-" + classifier
+		}
+		s.put(repository, dir+name+"."+fields[asset+".extension"], data)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
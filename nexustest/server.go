@@ -0,0 +1,180 @@
+// Package nexustest provides a fake Nexus server for hermetic tests.
+//
+// The Server emulates the Nexus 2 maven resolve, redirect, content, metadata and authentication endpoints
+// and the Nexus 3 assets, components, search, status and repository endpoints on the same
+// httptest.Server, so its URL is the host url of both a nexus2.ArtifactRequest and a nexus3.Client.
+// Version limits the server to the endpoints of one generation.
+// Files live in memory by repository and path:
+//
+//	s := nexustest.NewServer()
+//	defer s.Close()
+//	s.Put("releases", "com/example/app/1.0/app-1.0.jar", []byte("jar"))
+//	aRequest := nexus2.ArtifactRequest{HostURL: s.URL, Username: s.Username, Password: s.Password, ...}
+package nexustest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Nexus server backed by an in-memory store
+type Server struct {
+	*httptest.Server
+	// Username and Password are the credentials accepted by the server. Anonymous requests may read,
+	// any other request needs them. Set both to empty strings to allow anonymous writes.
+	Username, Password string
+	// Version restricts the server to the endpoints of Nexus 2 or Nexus 3. Both are served when zero.
+	Version int
+
+	mu    sync.Mutex
+	repos map[string]map[string]*file
+}
+
+// file is a stored file
+type file struct {
+	data     []byte
+	modified time.Time
+}
+
+func (f *file) sha1() string {
+	sum := sha1.Sum(f.data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *file) md5() string {
+	sum := md5.Sum(f.data)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewServer starts a server accepting the admin/admin123 credentials of a fresh Nexus installation
+func NewServer() *Server {
+	s := &Server{Username: "admin", Password: "admin123", repos: map[string]map[string]*file{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Put stores a file in a repository, creating the repository when needed
+func (s *Server) Put(repository, path string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(repository, path, data)
+}
+
+func (s *Server) put(repository, path string, data []byte) {
+	repo, found := s.repos[repository]
+	if !found {
+		repo = map[string]*file{}
+		s.repos[repository] = repo
+	}
+	repo[strings.Trim(path, "/")] = &file{data: append([]byte(nil), data...), modified: time.Now().UTC()}
+}
+
+// Get returns the content of a stored file
+func (s *Server) Get(repository, path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, found := s.repos[repository][strings.Trim(path, "/")]
+	if !found {
+		return nil, false
+	}
+	return append([]byte(nil), f.data...), true
+}
+
+// Delete removes a file, or every file below a directory, and reports whether anything was removed
+func (s *Server) Delete(repository, path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(repository, path)
+}
+
+func (s *Server) delete(repository, path string) bool {
+	path = strings.Trim(path, "/")
+	deleted := false
+	for p := range s.repos[repository] {
+		if p == path || path == "" || strings.HasPrefix(p, path+"/") {
+			delete(s.repos[repository], p)
+			deleted = true
+		}
+	}
+	return deleted
+}
+
+// Paths returns the sorted paths of the files of a repository
+func (s *Server) Paths(repository string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths(repository, "")
+}
+
+// paths returns the sorted paths of the files below a directory
+func (s *Server) paths(repository, dir string) []string {
+	dir = strings.Trim(dir, "/")
+	var paths []string
+	for p := range s.repos[repository] {
+		if dir == "" || strings.HasPrefix(p, dir+"/") {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// authorized checks the credentials of a request. Requests without credentials may only read.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	switch {
+	case s.Username == "" && s.Password == "":
+		return true
+	case ok && username == s.Username && password == s.Password:
+		return true
+	case !ok && r.Header.Get("Authorization") == "" && (r.Method == "GET" || r.Method == "HEAD"):
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="Sonatype Nexus Repository Manager"`)
+	http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// serveHTTP dispatches a request to the Nexus 2 or Nexus 3 endpoints
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nexus2 := strings.HasPrefix(r.URL.Path, "/service/local/") || strings.HasPrefix(r.URL.Path, "/content/")
+	switch {
+	case nexus2 && s.Version != 3:
+		s.serveNexus2(w, r)
+	case !nexus2 && s.Version != 2:
+		s.serveNexus3(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// id returns the opaque id of a repository path, used for Nexus 3 assets and components
+func id(repository, path string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(repository + "/" + path))
+}
+
+// parseID returns the repository and path of an id
+func parseID(s string) (string, string, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(string(b), "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
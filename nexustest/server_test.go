@@ -0,0 +1,85 @@
+package nexustest_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bzon/nexus-cli/auth"
+	"github.com/bzon/nexus-cli/nexus2"
+	"github.com/bzon/nexus-cli/nexus3"
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+func TestNexus2Content(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Put("releases", "com/example/app/1.0/app-1.0.jar", []byte("jar"))
+	server.Put("releases", "com/example/app/1.0/app-1.0.pom", []byte("pom"))
+
+	aRequest := nexus2.ArtifactRequest{HostURL: server.URL, Username: server.Username, Password: server.Password}
+	files, err := nexus2.ListFiles(aRequest, "releases", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].RelativePath != "com/example/app/1.0/app-1.0.jar" || files[0].SizeOnDisk != 3 {
+		t.Errorf("ListFiles() = %+v", files)
+	}
+	sum, err := nexus2.ContentSha1(aRequest, "releases", "com/example/app/1.0/app-1.0.jar")
+	if err != nil || sum != "f92e777f4341930bad9b2422283c4680d00dbc06" {
+		t.Errorf("ContentSha1() = %s, %v", sum, err)
+	}
+	if err := nexus2.DeleteContent(aRequest, "releases", "com/example/app/1.0/"); err != nil {
+		t.Fatal(err)
+	}
+	if paths := server.Paths("releases"); len(paths) != 0 {
+		t.Errorf("Paths() = %v after deleting the directory", paths)
+	}
+	if err := nexus2.Login(nexus2.ArtifactRequest{HostURL: server.URL, Auth: auth.Anonymous{}}); err == nil {
+		t.Error("Login() without credentials should fail")
+	}
+}
+
+func TestNexus3Components(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nexustest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jar := filepath.Join(dir, "app.jar")
+	ioutil.WriteFile(jar, []byte("jar"), 0644)
+
+	server := nexustest.NewServer()
+	defer server.Close()
+	for i := 0; i < 12; i++ {
+		server.Put("maven-releases", fmt.Sprintf("com/example/lib/1.%d/lib-1.%d.jar", i, i), []byte("lib"))
+	}
+	client := nexus3.Client{HostURL: server.URL, Username: server.Username, Password: server.Password, Repository: "maven-releases"}
+
+	assets := []nexus3.MavenAsset{{File: jar, Extension: "jar"}, {File: jar, Classifier: "sources", Extension: "jar"}}
+	if err := client.UploadMavenComponent("com.example", "app", "2.0", assets); err != nil {
+		t.Fatal(err)
+	}
+	list, err := client.ListAssets("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 14 {
+		t.Errorf("ListAssets() returned %d assets over the pages, want 14", len(list))
+	}
+	c, err := client.FindComponent("com.example", "app", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Assets) != 2 || c.Assets[1].Path != "com/example/app/2.0/app-2.0.jar" {
+		t.Errorf("FindComponent() = %+v", c)
+	}
+	if err := client.DeleteComponent(c); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := server.Get("maven-releases", "com/example/app/2.0/app-2.0.jar"); found {
+		t.Error("DeleteComponent() left the jar")
+	}
+}
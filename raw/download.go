@@ -0,0 +1,107 @@
+package raw
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// DownloadResult lists the local files written or skipped by Download
+type DownloadResult struct {
+	Downloaded []string
+	Skipped    []string
+}
+
+// Download downloads every file stored under directory into destDir, keeping the directory structure.
+// Files are downloaded by Parallel workers and verified against their sha1.
+// Files whose local sha1 already matches the remote file are skipped.
+func (r Repository) Download(directory, destDir string) (*DownloadResult, error) {
+	directory = strings.Trim(directory, "/")
+	assets, err := r.list(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(DownloadResult)
+	var errs []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan nexus.Asset)
+	for i := 0; i < r.parallel(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range jobs {
+				filePath, skipped, err := r.downloadAsset(a, directory, destDir)
+				mu.Lock()
+				switch {
+				case err != nil:
+					log.Errorf("Failed downloading %s: %v", a.Path, err)
+					errs = append(errs, fmt.Sprintf("%s: %v", a.Path, err))
+				case skipped:
+					log.Debugf("%s is up to date", filePath)
+					result.Skipped = append(result.Skipped, filePath)
+				default:
+					log.Infof("Downloaded %s", filePath)
+					result.Downloaded = append(result.Downloaded, filePath)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, a := range assets {
+		jobs <- a
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(result.Downloaded)
+	sort.Strings(result.Skipped)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return result, fmt.Errorf("%d of %d files failed:\n%s", len(errs), len(assets), strings.Join(errs, "\n"))
+	}
+	return result, nil
+}
+
+// downloadAsset writes a single file below destDir and reports whether it was skipped
+func (r Repository) downloadAsset(a nexus.Asset, directory, destDir string) (string, bool, error) {
+	rel := strings.TrimPrefix(a.Path, directory+"/")
+	if directory == "" {
+		rel = a.Path
+	}
+	filePath := filepath.Join(destDir, filepath.FromSlash(rel))
+	if rel, err := filepath.Rel(destDir, filePath); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, fmt.Errorf("refusing to write %s outside of %s", a.Path, destDir)
+	}
+	if localSha1, err := fileSha1(filePath); err == nil && a.Sha1 != "" && strings.EqualFold(localSha1, a.Sha1) {
+		return filePath, true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", false, err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filePath), ".download-")
+	if err != nil {
+		return "", false, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	localSha1, err := r.Client.Download(r.Name, a.Path, tmp.Name())
+	if err != nil {
+		return "", false, err
+	}
+	if a.Sha1 != "" && !strings.EqualFold(a.Sha1, localSha1) {
+		return "", false, fmt.Errorf("sha1 mismatch: remote %s, downloaded %s", a.Sha1, localSha1)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return "", false, err
+	}
+	return filePath, false, os.Rename(tmp.Name(), filePath)
+}
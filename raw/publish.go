@@ -0,0 +1,183 @@
+package raw
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+const (
+	// SiteVersionsFile is the name of the version index written at the root of a published site
+	SiteVersionsFile = "versions.json"
+	// SiteLatestDirectory is the name of the alias directory pointing at the newest version
+	SiteLatestDirectory = "latest"
+)
+
+// SitePublication holds the fields required for publishing a versioned site
+type SitePublication struct {
+	// Source is the local directory holding the generated site
+	Source string
+	// Directory is the project root inside the raw repository, for example site/myproject
+	Directory string
+	// Version is the directory the site is published under
+	Version string
+	// CopyLatest copies the whole tree into latest/ instead of writing a redirect page
+	CopyLatest bool
+}
+
+// SiteVersions is the content of the versions.json index
+type SiteVersions struct {
+	Latest   string   `json:"latest"`
+	Versions []string `json:"versions"`
+}
+
+var siteIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Project}}</title></head>
+<body>
+<h1>{{.Project}}</h1>
+<ul>
+<li><a href="latest/">latest</a> ({{.Latest}})</li>
+{{range .Versions}}<li><a href="{{.}}/">{{.}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+var siteRedirectTemplate = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url=../{{.}}/">
+<link rel="canonical" href="../{{.}}/">
+<title>Redirecting to {{.}}</title>
+</head>
+<body><a href="../{{.}}/">{{.}}</a></body>
+</html>
+`))
+
+// PublishSite uploads the site to <Directory>/<Version>, registers the version in versions.json,
+// regenerates the root index.html and points the latest/ alias at the new version
+func (r Repository) PublishSite(p SitePublication) (*SiteVersions, error) {
+	if p.Version == "" || strings.Contains(p.Version, "/") || p.Version == SiteLatestDirectory {
+		return nil, fmt.Errorf("invalid site version %q", p.Version)
+	}
+	dir := strings.Trim(p.Directory, "/")
+	log.Infof("Publishing %s to %s/%s/%s", p.Source, r.URL(), dir, p.Version)
+	if err := r.syncDirectory(p.Source, joinPath(dir, p.Version)); err != nil {
+		return nil, err
+	}
+
+	versions, err := r.GetSiteVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+	versions.add(p.Version)
+
+	// latest/ alias, left alone when an older version is republished
+	if p.Version == versions.Latest {
+		if p.CopyLatest {
+			err = r.syncDirectory(p.Source, joinPath(dir, SiteLatestDirectory))
+		} else {
+			err = r.redirectLatest(joinPath(dir, SiteLatestDirectory), p.Version)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// root index
+	b, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.uploadContent(dir, SiteVersionsFile, b); err != nil {
+		return nil, err
+	}
+	project := dir[strings.LastIndex(dir, "/")+1:]
+	err = r.uploadTemplate(dir, "index.html", siteIndexTemplate, struct {
+		Project string
+		*SiteVersions
+	}{project, versions})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetSiteVersions reads the versions.json index of a published site.
+// An empty index is returned when the site has not been published yet.
+func (r Repository) GetSiteVersions(directory string) (*SiteVersions, error) {
+	tmp, err := ioutil.TempFile("", "nexus-raw")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	versions := new(SiteVersions)
+	p := joinPath(strings.Trim(directory, "/"), SiteVersionsFile)
+	if _, err := r.Client.Download(r.Name, p, tmp.Name()); errors.Is(err, nexus.ErrNotFound) {
+		return versions, nil
+	} else if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, versions); err != nil {
+		return nil, fmt.Errorf("%s/%s: %v", r.URL(), p, err)
+	}
+	return versions, nil
+}
+
+// add registers a version, keeps the versions sorted newest first and makes the newest one the latest
+func (v *SiteVersions) add(version string) {
+	found := false
+	for _, existing := range v.Versions {
+		if existing == version {
+			found = true
+		}
+	}
+	if !found {
+		v.Versions = append(v.Versions, version)
+	}
+	sort.Slice(v.Versions, func(i, j int) bool { return maven.CompareVersions(v.Versions[i], v.Versions[j]) > 0 })
+	v.Latest = v.Versions[0]
+}
+
+// redirectLatest writes the redirect page of the latest/ directory and deletes the files left
+// there by a previous copy
+func (r Repository) redirectLatest(directory, version string) error {
+	assets, err := r.Client.List(r.Name, directory)
+	if err != nil {
+		return err
+	}
+	for _, a := range assets {
+		if a.Path == joinPath(directory, "index.html") {
+			continue
+		}
+		if err := r.Client.Delete(r.Name, a.Path); err != nil {
+			return err
+		}
+		log.Infof("Deleted %s", a.Path)
+	}
+	return r.uploadTemplate(directory, "index.html", siteRedirectTemplate, version)
+}
+
+func (r Repository) uploadTemplate(directory, filename string, t *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	return r.uploadContent(directory, filename, buf.Bytes())
+}
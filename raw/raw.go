@@ -0,0 +1,118 @@
+// Package raw synchronises, publishes and downloads the directories of the raw repositories of Nexus 2
+// and Nexus 3 servers through a nexus.Client.
+package raw
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bzon/nexus-cli/logging"
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// log receives the progress messages of the package
+var log, _ = logging.New(os.Stderr, logging.LevelInfo, "text")
+
+// SetLogger replaces the logger receiving the progress messages of the package.
+// A nil logger discards every message.
+func SetLogger(l logging.Logger) {
+	if l == nil {
+		l = logging.Discard
+	}
+	log = l
+}
+
+// Repository is a raw repository of a Nexus server
+type Repository struct {
+	Client nexus.Client
+	Name   string
+	// Parallel is the number of files downloaded at a time, and of the sha1 asked at a time from the
+	// servers that do not list them. It is 1 when lower.
+	Parallel int
+}
+
+// URL returns the url of the repository, below which its files are read and written
+func (r Repository) URL() string {
+	return r.Client.URL(r.Name)
+}
+
+// File is a local file and the path it is stored under in the repository
+type File struct {
+	// File is the path of the local file
+	File string
+	// Filename is the path of the file below Directory
+	Filename string
+	// Directory is the directory inside the repository, empty for the repository root
+	Directory string
+}
+
+// Upload uploads a local file and returns the url of the uploaded file
+func (r Repository) Upload(f File) (string, error) {
+	p := joinPath(strings.Trim(f.Directory, "/"), f.Filename)
+	if err := r.Client.Upload(r.Name, p, f.File); err != nil {
+		return "", err
+	}
+	return r.URL() + "/" + p, nil
+}
+
+// uploadContent uploads content as directory/filename
+func (r Repository) uploadContent(directory, filename string, content []byte) error {
+	tmp, err := ioutil.TempFile("", "nexus-raw")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	_, err = r.Upload(File{File: tmp.Name(), Filename: filename, Directory: directory})
+	return err
+}
+
+// list returns the files below a directory with their sha1
+func (r Repository) list(directory string) ([]nexus.Asset, error) {
+	assets, err := r.Client.List(r.Name, directory)
+	if err != nil {
+		return nil, err
+	}
+	if err := nexus.Checksums(r.Client, r.Name, assets, r.parallel()); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+func (r Repository) parallel() int {
+	if r.Parallel < 1 {
+		return 1
+	}
+	return r.Parallel
+}
+
+// fileSha1 returns the hex encoded sha1 of a file
+func fileSha1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func joinPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
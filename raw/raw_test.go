@@ -0,0 +1,169 @@
+package raw
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+// newRepository returns the site repository of a fake server serving the endpoints of one Nexus version
+func newRepository(t *testing.T, version int) (*nexustest.Server, Repository) {
+	server := nexustest.NewServer()
+	server.Version = version
+	c, err := nexus.New(version, nexus.Server{HostURL: server.URL, Username: server.Username, Password: server.Password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, Repository{Client: c, Name: "site", Parallel: 2}
+}
+
+func TestPlanSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "site-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "css"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("foo"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "css", "site.css"), []byte("bar"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "new.html"), []byte("baz"), 0644)
+
+	for _, version := range []int{2, 3} {
+		// index.html matches, css/site.css differs and old.html is stale
+		server, repo := newRepository(t, version)
+		server.Put("site", "docs/index.html", []byte("foo"))
+		server.Put("site", "docs/css/site.css", []byte("old"))
+		server.Put("site", "docs/old.html", []byte("old"))
+		server.Put("site", "other/index.html", []byte("other"))
+
+		plan, err := repo.PlanSync(dir, "/docs/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Added) != 1 || plan.Added[0].Filename != "new.html" {
+			t.Errorf("Nexus %d: Added = %v, want [new.html]", version, plan.Added)
+		}
+		if len(plan.Changed) != 1 || plan.Changed[0].Filename != "css/site.css" {
+			t.Errorf("Nexus %d: Changed = %v, want [css/site.css]", version, plan.Changed)
+		}
+		if len(plan.Unchanged) != 1 || plan.Unchanged[0].Filename != "index.html" {
+			t.Errorf("Nexus %d: Unchanged = %v, want [index.html]", version, plan.Unchanged)
+		}
+		if len(plan.Stale) != 1 || plan.Stale[0].Path != "docs/old.html" {
+			t.Errorf("Nexus %d: Stale = %v, want [docs/old.html]", version, plan.Stale)
+		}
+
+		if err := repo.ApplySync(plan, true); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := server.Get("site", "docs/css/site.css"); string(b) != "bar" {
+			t.Errorf("Nexus %d: docs/css/site.css = %q after the sync, want %q", version, b, "bar")
+		}
+		if _, found := server.Get("site", "docs/old.html"); found {
+			t.Errorf("Nexus %d: ApplySync() with prune left docs/old.html", version)
+		}
+
+		// a directory that does not exist yet has no remote file
+		plan, err = repo.PlanSync(dir, "new")
+		if err != nil || len(plan.Added) != 3 {
+			t.Errorf("Nexus %d: PlanSync() of a new directory = %+v, %v, want 3 files to add", version, plan, err)
+		}
+		server.Close()
+	}
+}
+
+func TestPublishSite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "site-publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("site"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "page.html"), []byte("page"), 0644)
+
+	for _, version := range []int{2, 3} {
+		server, repo := newRepository(t, version)
+		server.Put("site", "README", []byte("site repository"))
+
+		publish := func(v string, copyLatest bool) *SiteVersions {
+			versions, err := repo.PublishSite(SitePublication{Source: dir, Directory: "docs/app", Version: v, CopyLatest: copyLatest})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return versions
+		}
+		publish("2.0", true)
+		if _, found := server.Get("site", "docs/app/latest/page.html"); !found {
+			t.Errorf("Nexus %d: PublishSite() with CopyLatest did not copy the site into latest/", version)
+		}
+		publish("2.0", false)
+		if _, found := server.Get("site", "docs/app/latest/page.html"); found {
+			t.Errorf("Nexus %d: PublishSite() with a redirect left the copied files in latest/", version)
+		}
+
+		// a hotfix of an older version must not move latest/ backwards
+		versions := publish("1.1", false)
+		if versions.Latest != "2.0" || strings.Join(versions.Versions, ",") != "2.0,1.1" {
+			t.Errorf("Nexus %d: PublishSite(1.1) = %+v, want 2.0 as the latest", version, versions)
+		}
+		redirect, _ := server.Get("site", "docs/app/latest/index.html")
+		if !strings.Contains(string(redirect), "../2.0/") {
+			t.Errorf("Nexus %d: latest/index.html = %s, want a redirect to 2.0", version, redirect)
+		}
+		server.Close()
+	}
+}
+
+func TestDownload(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		dir, err := ioutil.TempDir("", "raw-download")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		server, repo := newRepository(t, version)
+		server.Put("site", "docs/index.html", []byte("foo"))
+		server.Put("site", "docs/css/site.css", []byte("bar"))
+		server.Put("site", "other/index.html", []byte("other"))
+
+		result, err := repo.Download("docs", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Downloaded) != 2 {
+			t.Errorf("Nexus %d: Downloaded = %v, want 2 files", version, result.Downloaded)
+		}
+		if b, _ := ioutil.ReadFile(filepath.Join(dir, "css", "site.css")); string(b) != "bar" {
+			t.Errorf("Nexus %d: css/site.css = %q, want %q", version, b, "bar")
+		}
+
+		result, err = repo.Download("docs", dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Skipped) != 2 || len(result.Downloaded) != 0 {
+			t.Errorf("Nexus %d: second download should skip every file, got %+v", version, result)
+		}
+
+		// Download into the working directory with -d .
+		cwd, _ := os.Getwd()
+		if err := os.Chdir(filepath.Join(dir, "css")); err != nil {
+			t.Fatal(err)
+		}
+		result, err = repo.Download("docs", ".")
+		os.Chdir(cwd)
+		if err != nil {
+			t.Fatalf("Nexus %d: %v", version, err)
+		}
+		if b, _ := ioutil.ReadFile(filepath.Join(dir, "css", "index.html")); string(b) != "foo" {
+			t.Errorf("Nexus %d: index.html in the working directory = %q, want %q", version, b, "foo")
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,104 @@
+package raw
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// SyncPlan lists the changes needed to make a remote directory match a local directory
+type SyncPlan struct {
+	// Added are local files that do not exist remotely
+	Added []File
+	// Changed are local files whose sha1 differs from the remote file
+	Changed []File
+	// Unchanged are local files that already match the remote file
+	Unchanged []File
+	// Stale are remote files that no longer exist locally
+	Stale []nexus.Asset
+}
+
+// PlanSync compares the files under localDir with the files stored under directory
+func (r Repository) PlanSync(localDir, directory string) (*SyncPlan, error) {
+	directory = strings.Trim(directory, "/")
+	assets, err := r.list(directory)
+	if err != nil {
+		return nil, err
+	}
+	remote := make(map[string]nexus.Asset, len(assets))
+	for _, a := range assets {
+		remote[strings.TrimPrefix(a.Path, directory+"/")] = a
+	}
+
+	plan := new(SyncPlan)
+	err = filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+		f := File{File: path, Filename: filepath.ToSlash(rel), Directory: directory}
+		a, found := remote[f.Filename]
+		if !found {
+			plan.Added = append(plan.Added, f)
+			return nil
+		}
+		delete(remote, f.Filename)
+		localSha1, err := fileSha1(path)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(localSha1, a.Sha1) {
+			plan.Unchanged = append(plan.Unchanged, f)
+		} else {
+			plan.Changed = append(plan.Changed, f)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range remote {
+		plan.Stale = append(plan.Stale, a)
+	}
+	sort.Slice(plan.Stale, func(i, j int) bool { return plan.Stale[i].Path < plan.Stale[j].Path })
+	return plan, nil
+}
+
+// ApplySync uploads the added and changed files of the plan.
+// Stale remote files are deleted only when prune is true.
+func (r Repository) ApplySync(plan *SyncPlan, prune bool) error {
+	for _, list := range [][]File{plan.Added, plan.Changed} {
+		for _, f := range list {
+			uri, err := r.Upload(f)
+			if err != nil {
+				return err
+			}
+			log.Infof("Uploaded %s", uri)
+		}
+	}
+	if !prune {
+		return nil
+	}
+	for _, a := range plan.Stale {
+		if err := r.Client.Delete(r.Name, a.Path); err != nil {
+			return err
+		}
+		log.Infof("Deleted %s", a.Path)
+	}
+	return nil
+}
+
+// syncDirectory makes the remote directory an exact copy of the local one
+func (r Repository) syncDirectory(localDir, directory string) error {
+	plan, err := r.PlanSync(localDir, directory)
+	if err != nil {
+		return err
+	}
+	return r.ApplySync(plan, true)
+}
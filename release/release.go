@@ -0,0 +1,278 @@
+// Package release manages versioned installations: <base>/releases/<version> directories
+// and a <base>/current symlink that is switched atomically.
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bzon/nexus-cli/archive"
+)
+
+// Release is an installed version
+type Release struct {
+	Version     string    `json:"version" yaml:"version"`
+	Group       string    `json:"group" yaml:"group"`
+	Artifact    string    `json:"artifact" yaml:"artifact"`
+	Coordinates string    `json:"coordinates" yaml:"coordinates"`
+	Sha1        string    `json:"sha1" yaml:"sha1"`
+	InstalledAt time.Time `json:"installedAt" yaml:"installedAt"`
+	ActivatedAt time.Time `json:"activatedAt,omitempty" yaml:"activatedAt,omitempty"`
+}
+
+// Base is the base directory of an installation.
+// The installed releases are recorded in <base>/releases.json, the least recently activated first.
+type Base struct {
+	Dir string
+}
+
+// ReleaseDir returns the directory of a version
+func (b Base) ReleaseDir(version string) string {
+	return filepath.Join(b.Dir, "releases", version)
+}
+
+func (b Base) currentPath() string {
+	return filepath.Join(b.Dir, "current")
+}
+
+func (b Base) historyPath() string {
+	return filepath.Join(b.Dir, "releases.json")
+}
+
+// CheckVersion returns an error when a version cannot be used as a directory name
+func CheckVersion(version string) error {
+	if version == "" || version == "." || version == ".." || strings.ContainsAny(version, `/\`) {
+		return fmt.Errorf("invalid release version %q", version)
+	}
+	return nil
+}
+
+// History returns the installed releases, the least recently activated first
+func (b Base) History() ([]Release, error) {
+	var history []Release
+	data, err := ioutil.ReadFile(b.historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s: %v", b.historyPath(), err)
+	}
+	return history, nil
+}
+
+func (b Base) writeHistory(history []Release) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := b.historyPath() + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.historyPath())
+}
+
+// Installed reports whether the directory of a version exists
+func (b Base) Installed(version string) bool {
+	info, err := os.Stat(b.ReleaseDir(version))
+	return err == nil && info.IsDir()
+}
+
+// Current returns the version the current symlink points to, empty when there is none
+func (b Base) Current() (string, error) {
+	target, err := os.Readlink(b.currentPath())
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// Stage creates an empty staging directory next to the releases, to be committed with Commit
+func (b Base) Stage(version string) (string, error) {
+	if err := CheckVersion(version); err != nil {
+		return "", err
+	}
+	releases := filepath.Join(b.Dir, "releases")
+	if err := os.MkdirAll(releases, 0755); err != nil {
+		return "", err
+	}
+	return ioutil.TempDir(releases, ".staging-"+version+"-")
+}
+
+// Place moves a downloaded artifact into a staging directory, or unpacks it there when its packaging
+// is an archive (zip, tar, tar.gz, tar.bz2 or tar.xz). A jar, war or ear file is placed as it is.
+// It reports whether the artifact was unpacked.
+func Place(file, staging, packaging string, opts archive.Options) (bool, error) {
+	if _, ok := archive.ForPackaging(packaging); ok {
+		_, err := archive.Extract(file, staging, opts)
+		return true, err
+	}
+	return false, os.Rename(file, filepath.Join(staging, filepath.Base(file)))
+}
+
+// Commit renames a staging directory to the directory of the release and records the release.
+// The current release is refused, as replacing its directory would leave current pointing at nothing.
+func (b Base) Commit(staging string, r Release) error {
+	if err := b.checkReplaceable(r.Version); err != nil {
+		return err
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(b.ReleaseDir(r.Version)); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, b.ReleaseDir(r.Version)); err != nil {
+		return err
+	}
+	history, err := b.History()
+	if err != nil {
+		return err
+	}
+	history = append(without(history, r.Version), r)
+	return b.writeHistory(history)
+}
+
+// checkReplaceable returns an error when the directory of a version is the current release
+func (b Base) checkReplaceable(version string) error {
+	current, err := b.Current()
+	if err != nil {
+		return err
+	}
+	if version == current && b.Installed(version) {
+		return fmt.Errorf("release %s is current in %s, switch to another release before replacing it", version, b.Dir)
+	}
+	return nil
+}
+
+// Activate points the current symlink to a version and moves it to the end of the history.
+// A new symlink is renamed over the current one, so there is no moment without a current release.
+func (b Base) Activate(version string) error {
+	if !b.Installed(version) {
+		return fmt.Errorf("release %s is not installed in %s", version, b.Dir)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", b.currentPath(), os.Getpid())
+	os.Remove(tmp)
+	if err := os.Symlink(filepath.Join("releases", version), tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.currentPath()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	history, err := b.History()
+	if err != nil {
+		return err
+	}
+	for _, r := range history {
+		if r.Version == version {
+			r.ActivatedAt = time.Now()
+			return b.writeHistory(append(without(history, version), r))
+		}
+	}
+	return nil
+}
+
+// Previous returns the release that was active before the current one and is still on disk
+func (b Base) Previous() (Release, error) {
+	current, err := b.Current()
+	if err != nil {
+		return Release{}, err
+	}
+	history, err := b.History()
+	if err != nil {
+		return Release{}, err
+	}
+	index := len(history)
+	for i, r := range history {
+		if r.Version == current {
+			index = i
+		}
+	}
+	for i := index - 1; i >= 0; i-- {
+		if b.Installed(history[i].Version) {
+			return history[i], nil
+		}
+	}
+	return Release{}, fmt.Errorf("no release active before %q to roll back to in %s", current, b.Dir)
+}
+
+// Prune removes the least recently activated releases so that at most keep releases remain.
+// The current release is never removed, and a release rolled back from is removed before the
+// one it was rolled back to. The removed versions are returned.
+func (b Base) Prune(keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, nil
+	}
+	current, err := b.Current()
+	if err != nil {
+		return nil, err
+	}
+	history, err := b.History()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i := 0; i < len(history) && len(history)-len(removed) > keep; i++ {
+		version := history[i].Version
+		if version == current {
+			continue
+		}
+		if err := os.RemoveAll(b.ReleaseDir(version)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, version)
+	}
+	for _, version := range removed {
+		history = without(history, version)
+	}
+	return removed, b.writeHistory(history)
+}
+
+func without(history []Release, version string) []Release {
+	kept := history[:0:0]
+	for _, r := range history {
+		if r.Version != version {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// RunHook runs a hook command with sh -c in the base directory.
+// env is added to the environment and the output goes to stderr.
+func (b Base) RunHook(command string, env map[string]string) error {
+	if command == "" {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = b.Dir
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("hook %q: %v", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package release
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bzon/nexus-cli/archive"
+)
+
+func install(t *testing.T, b Base, version string, at time.Time) {
+	staging, err := b.Stage(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(staging, "VERSION"), []byte(version), 0644)
+	if err := b.Commit(staging, Release{Version: version, InstalledAt: at}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Activate(version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b := Base{Dir: dir}
+
+	start := time.Now()
+	for i, version := range []string{"1.0", "1.1", "1.2"} {
+		install(t, b, version, start.Add(time.Duration(i)*time.Minute))
+	}
+	if current, _ := b.Current(); current != "1.2" {
+		t.Errorf("got current %s", current)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "current", "VERSION")); string(data) != "1.2" {
+		t.Errorf("got %q through the current symlink", data)
+	}
+
+	staging, err := b.Stage("1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(staging, Release{Version: "1.2"}); err == nil {
+		t.Error("expected the current release not to be replaced")
+	}
+	os.RemoveAll(staging)
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "current", "VERSION")); string(data) != "1.2" {
+		t.Errorf("got %q through the current symlink after replacing it", data)
+	}
+
+	previous, err := b.Previous()
+	if err != nil || previous.Version != "1.1" {
+		t.Fatalf("got %+v, %v", previous, err)
+	}
+	if err := b.Activate(previous.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := b.Prune(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"1.0", "1.2"}) {
+		t.Errorf("got removed %v", removed)
+	}
+	if history, _ := b.History(); len(history) != 1 || history[0].Version != "1.1" {
+		t.Errorf("got history %+v", history)
+	}
+	if _, err := b.Previous(); err == nil {
+		t.Error("expected no release to roll back to")
+	}
+}
+
+func TestPruneAfterRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b := Base{Dir: dir}
+
+	start := time.Now()
+	install(t, b, "1.0", start)
+	install(t, b, "2.0", start.Add(time.Minute))
+	// 2.0 is broken: roll back to 1.0, then install 3.0
+	if err := b.Activate("1.0"); err != nil {
+		t.Fatal(err)
+	}
+	install(t, b, "3.0", start.Add(2*time.Minute))
+	if previous, err := b.Previous(); err != nil || previous.Version != "1.0" {
+		t.Errorf("got previous %+v, %v, want the release rolled back to", previous, err)
+	}
+	removed, err := b.Prune(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"2.0"}) {
+		t.Errorf("got removed %v, want the release rolled back from", removed)
+	}
+	if !b.Installed("1.0") || !b.Installed("3.0") {
+		t.Error("expected 1.0 and 3.0 to be kept")
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b := Base{Dir: dir}
+	if err := b.RunHook(`echo "$NEXUS_VERSION" > hook.out`, map[string]string{"NEXUS_VERSION": "2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "hook.out")); strings.TrimSpace(string(data)) != "2.0" {
+		t.Errorf("got %q", data)
+	}
+	if err := b.RunHook("exit 3", nil); err == nil {
+		t.Error("expected the failing hook to return an error")
+	}
+}
+
+func TestPlace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b := Base{Dir: dir}
+
+	// A jar is a zip file, it must be placed as it is
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("META-INF/MANIFEST.MF")
+	w.Write([]byte("Manifest-Version: 1.0\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	place := func(packaging string) string {
+		file := filepath.Join(dir, "app."+packaging)
+		if err := ioutil.WriteFile(file, zipped.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		staging, err := b.Stage("1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		unpacked, err := Place(file, staging, packaging, archive.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unpacked != (packaging == "zip") {
+			t.Errorf("%s: got unpacked %v", packaging, unpacked)
+		}
+		return staging
+	}
+
+	staging := place("jar")
+	if data, _ := ioutil.ReadFile(filepath.Join(staging, "app.jar")); !bytes.Equal(data, zipped.Bytes()) {
+		t.Error("the jar file was not kept as it is")
+	}
+	if _, err := os.Stat(filepath.Join(staging, "META-INF")); err == nil {
+		t.Error("the jar file was unpacked")
+	}
+	staging = place("zip")
+	if _, err := os.Stat(filepath.Join(staging, "META-INF", "MANIFEST.MF")); err != nil {
+		t.Error(err)
+	}
+}
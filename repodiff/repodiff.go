@@ -0,0 +1,132 @@
+// Package repodiff compares the assets of two repositories, on the same or on different Nexus servers.
+package repodiff
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// Asset is a file of a repository with its sha1
+type Asset struct {
+	Path string `json:"path" yaml:"path"`
+	Sha1 string `json:"sha1" yaml:"sha1"`
+}
+
+// Change is an asset stored with different checksums on both sides
+type Change struct {
+	Path      string `json:"path" yaml:"path"`
+	LeftSha1  string `json:"leftSha1" yaml:"leftSha1"`
+	RightSha1 string `json:"rightSha1" yaml:"rightSha1"`
+}
+
+// Result lists the differences between a left and a right repository. A component is the directory
+// of its assets, <group path>/<artifact>/<version> in a Maven repository.
+type Result struct {
+	Left        string `json:"left" yaml:"left"`
+	Right       string `json:"right" yaml:"right"`
+	LeftAssets  int    `json:"leftAssets" yaml:"leftAssets"`
+	RightAssets int    `json:"rightAssets" yaml:"rightAssets"`
+	// OnlyLeft and OnlyRight are the components missing from the other side
+	OnlyLeft  []string `json:"onlyLeft" yaml:"onlyLeft"`
+	OnlyRight []string `json:"onlyRight" yaml:"onlyRight"`
+	// AssetsOnlyLeft and AssetsOnlyRight are the assets missing from a component found on both sides
+	AssetsOnlyLeft  []string `json:"assetsOnlyLeft" yaml:"assetsOnlyLeft"`
+	AssetsOnlyRight []string `json:"assetsOnlyRight" yaml:"assetsOnlyRight"`
+	Changed         []Change `json:"changed" yaml:"changed"`
+	Identical       int      `json:"identical" yaml:"identical"`
+}
+
+// Equal reports whether both repositories hold the same assets with the same checksums
+func (r *Result) Equal() bool {
+	return len(r.OnlyLeft) == 0 && len(r.OnlyRight) == 0 && len(r.AssetsOnlyLeft) == 0 &&
+		len(r.AssetsOnlyRight) == 0 && len(r.Changed) == 0
+}
+
+// Compare compares the assets of two repositories. Maven metadata and checksum files are ignored:
+// each server generates its own.
+func Compare(left, right []Asset) *Result {
+	l, r := index(left), index(right)
+	result := &Result{LeftAssets: len(l), RightAssets: len(r)}
+	lComponents, rComponents := components(l), components(r)
+	for p, leftSha1 := range l {
+		rightSha1, found := r[p]
+		switch {
+		case !found && !rComponents[path.Dir(p)]:
+		case !found:
+			result.AssetsOnlyLeft = append(result.AssetsOnlyLeft, p)
+		case !strings.EqualFold(leftSha1, rightSha1):
+			result.Changed = append(result.Changed, Change{Path: p, LeftSha1: leftSha1, RightSha1: rightSha1})
+		default:
+			result.Identical++
+		}
+	}
+	for p := range r {
+		if _, found := l[p]; !found && lComponents[path.Dir(p)] {
+			result.AssetsOnlyRight = append(result.AssetsOnlyRight, p)
+		}
+	}
+	for c := range lComponents {
+		if !rComponents[c] {
+			result.OnlyLeft = append(result.OnlyLeft, c)
+		}
+	}
+	for c := range rComponents {
+		if !lComponents[c] {
+			result.OnlyRight = append(result.OnlyRight, c)
+		}
+	}
+	sort.Strings(result.OnlyLeft)
+	sort.Strings(result.OnlyRight)
+	sort.Strings(result.AssetsOnlyLeft)
+	sort.Strings(result.AssetsOnlyRight)
+	sort.Slice(result.Changed, func(i, j int) bool { return result.Changed[i].Path < result.Changed[j].Path })
+	return result
+}
+
+// index returns the sha1 of the compared assets by path
+func index(assets []Asset) map[string]string {
+	sums := make(map[string]string, len(assets))
+	for _, a := range assets {
+		p := strings.TrimPrefix(a.Path, "/")
+		if !maven.Generated(path.Base(p)) {
+			sums[p] = a.Sha1
+		}
+	}
+	return sums
+}
+
+// components returns the set of component directories of the assets
+func components(sums map[string]string) map[string]bool {
+	set := map[string]bool{}
+	for p := range sums {
+		set[path.Dir(p)] = true
+	}
+	return set
+}
+
+// List lists the assets of a repository. The sha1 of the files that the server does not list with
+// their checksum, as Nexus 2, are asked by parallel workers.
+func List(c nexus.Client, repository string, parallel int) ([]Asset, error) {
+	all, err := c.List(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	var listed []nexus.Asset
+	for _, a := range all {
+		if !maven.Generated(path.Base(a.Path)) {
+			listed = append(listed, a)
+		}
+	}
+	if err := nexus.Checksums(c, repository, listed, parallel); err != nil {
+		return nil, err
+	}
+	assets := make([]Asset, 0, len(listed))
+	for _, a := range listed {
+		assets = append(assets, Asset{Path: a.Path, Sha1: a.Sha1})
+	}
+	return assets, nil
+}
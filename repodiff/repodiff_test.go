@@ -0,0 +1,44 @@
+package repodiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	left := []Asset{
+		{Path: "com/example/foo/1.0/foo-1.0.jar", Sha1: "aaa"},
+		{Path: "com/example/foo/1.0/foo-1.0.pom", Sha1: "bbb"},
+		{Path: "com/example/foo/1.0/foo-1.0-sources.jar", Sha1: "ccc"},
+		{Path: "com/example/foo/1.0/foo-1.0.jar.sha1", Sha1: "ddd"},
+		{Path: "com/example/foo/maven-metadata.xml", Sha1: "eee"},
+		{Path: "com/example/foo/1.1/foo-1.1.jar", Sha1: "fff"},
+	}
+	right := []Asset{
+		{Path: "/com/example/foo/1.0/foo-1.0.jar", Sha1: "AAA"},
+		{Path: "/com/example/foo/1.0/foo-1.0.pom", Sha1: "123"},
+		{Path: "/com/example/foo/1.0/foo-1.0-javadoc.jar", Sha1: "456"},
+		{Path: "/com/example/foo/maven-metadata.xml", Sha1: "789"},
+		{Path: "/com/example/bar/2.0/bar-2.0.jar", Sha1: "abc"},
+	}
+	result := Compare(left, right)
+	want := &Result{
+		LeftAssets:      4,
+		RightAssets:     4,
+		OnlyLeft:        []string{"com/example/foo/1.1"},
+		OnlyRight:       []string{"com/example/bar/2.0"},
+		AssetsOnlyLeft:  []string{"com/example/foo/1.0/foo-1.0-sources.jar"},
+		AssetsOnlyRight: []string{"com/example/foo/1.0/foo-1.0-javadoc.jar"},
+		Changed:         []Change{{Path: "com/example/foo/1.0/foo-1.0.pom", LeftSha1: "bbb", RightSha1: "123"}},
+		Identical:       1,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Compare() = %+v, want %+v", result, want)
+	}
+	if result.Equal() {
+		t.Error("Equal() = true for different repositories")
+	}
+	if !Compare(left, left).Equal() {
+		t.Error("Equal() = false for the same assets")
+	}
+}
@@ -0,0 +1,49 @@
+package snapshots
+
+import (
+	"strings"
+
+	"github.com/bzon/nexus-cli/maven"
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// Builds lists the builds of the -SNAPSHOT versions of a repository, every build being a component
+// with a timestamped version
+func Builds(c nexus.Client, repository string) ([]Build, error) {
+	components, err := c.Search(repository, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	var builds []Build
+	for _, component := range components {
+		timestamp, number, ok := maven.SnapshotBuild(component.Version)
+		if !ok {
+			continue
+		}
+		b := Build{
+			Group:       component.Group,
+			Artifact:    component.Artifact,
+			BaseVersion: maven.BaseVersion(component.Version),
+			Version:     component.Version,
+			Timestamp:   timestamp,
+			BuildNumber: number,
+			Component:   component,
+		}
+		for _, a := range component.Assets {
+			b.Size += a.Size
+		}
+		builds = append(builds, b)
+	}
+	return builds, nil
+}
+
+// Delete deletes the component of a build
+func Delete(c nexus.Client, repository string, b Build) error {
+	return c.DeleteComponent(repository, b.Component)
+}
+
+// RebuildMetadata rebuilds the maven-metadata.xml of the -SNAPSHOT directory of a build, which still
+// lists the deleted builds on Nexus 2 otherwise
+func RebuildMetadata(c nexus.Client, repository string, b Build) error {
+	return c.RebuildMetadata(repository, strings.Replace(b.Group, ".", "/", -1)+"/"+b.Artifact+"/"+b.BaseVersion)
+}
@@ -0,0 +1,120 @@
+// Package snapshots lists the timestamped builds of Maven snapshot versions and selects
+// the builds to purge by retention policy.
+package snapshots
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus"
+)
+
+// Build is a single timestamped deployment of a snapshot version, for example
+// 1.0-20180101.120000-3 of 1.0-SNAPSHOT
+type Build struct {
+	Group       string    `json:"group" yaml:"group"`
+	Artifact    string    `json:"artifact" yaml:"artifact"`
+	BaseVersion string    `json:"baseVersion" yaml:"baseVersion"`
+	Version     string    `json:"version" yaml:"version"`
+	Timestamp   time.Time `json:"timestamp" yaml:"timestamp"`
+	BuildNumber int       `json:"buildNumber" yaml:"buildNumber"`
+	// Size is the total size of the files of the build, when the server reports it
+	Size int64 `json:"size" yaml:"size"`
+	// Component holds the files of the build, as returned by the search of the repository
+	Component nexus.Component `json:"-" yaml:"-"`
+}
+
+// GAV returns the group:artifact:baseVersion of the build
+func (b Build) GAV() string {
+	return b.Group + ":" + b.Artifact + ":" + b.BaseVersion
+}
+
+// Policy selects the builds to purge. With Keep, the newest Keep builds of every GAV are kept.
+// With OlderThan, builds deployed within OlderThan of Now are kept. When both are set,
+// a build is purged only when neither keeps it.
+type Policy struct {
+	Keep      int
+	OlderThan time.Duration
+	Now       time.Time
+}
+
+// Filter selects builds by group. A pattern matches a group equal to it or below it,
+// so com.example matches com.example.app, and may contain * wildcards.
+// No Include pattern selects every group.
+type Filter struct {
+	Include, Exclude []string
+}
+
+// Match reports whether a group is selected by the filter
+func (f Filter) Match(group string) bool {
+	for _, pattern := range f.Exclude {
+		if matchGroup(pattern, group) {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if matchGroup(pattern, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGroup(pattern, group string) bool {
+	if group == pattern || strings.HasPrefix(group, pattern+".") {
+		return true
+	}
+	matched, _ := path.Match(pattern, group)
+	return matched
+}
+
+// Plan returns the builds of the selected groups that the policy purges, oldest first per GAV
+func Plan(builds []Build, policy Policy, filter Filter) []Build {
+	byGAV := map[string][]Build{}
+	var gavs []string
+	for _, b := range builds {
+		if !filter.Match(b.Group) {
+			continue
+		}
+		if _, found := byGAV[b.GAV()]; !found {
+			gavs = append(gavs, b.GAV())
+		}
+		byGAV[b.GAV()] = append(byGAV[b.GAV()], b)
+	}
+	sort.Strings(gavs)
+	var purge []Build
+	for _, gav := range gavs {
+		list := byGAV[gav]
+		sort.Slice(list, func(i, j int) bool {
+			if !list[i].Timestamp.Equal(list[j].Timestamp) {
+				return list[i].Timestamp.After(list[j].Timestamp)
+			}
+			return list[i].BuildNumber > list[j].BuildNumber
+		})
+		for i := len(list) - 1; i >= 0; i-- {
+			if policy.purges(i, list[i]) {
+				purge = append(purge, list[i])
+			}
+		}
+	}
+	return purge
+}
+
+// purges reports whether the policy purges a build, rank 0 being the newest build of its GAV
+func (p Policy) purges(rank int, b Build) bool {
+	if p.Keep <= 0 && p.OlderThan <= 0 {
+		return false
+	}
+	if p.Keep > 0 && rank < p.Keep {
+		return false
+	}
+	if p.OlderThan > 0 && !b.Timestamp.Before(p.Now.Add(-p.OlderThan)) {
+		return false
+	}
+	return true
+}
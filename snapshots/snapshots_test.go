@@ -0,0 +1,120 @@
+package snapshots
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bzon/nexus-cli/nexus"
+	"github.com/bzon/nexus-cli/nexustest"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC)
+	build := func(group string, day, number int) Build {
+		return Build{
+			Group:       group,
+			Artifact:    "app",
+			BaseVersion: "1.0-SNAPSHOT",
+			Version:     fmt.Sprintf("1.0-201810%02d.120000-%d", day, number),
+			Timestamp:   time.Date(2018, 10, day, 12, 0, 0, 0, time.UTC),
+			BuildNumber: number,
+		}
+	}
+	builds := []Build{
+		build("com.example", 1, 1), build("com.example", 20, 3), build("com.example", 10, 2), build("com.example", 30, 4),
+		build("com.example.legacy", 1, 1), build("com.example.legacy", 2, 2),
+		build("org.other", 1, 1), build("org.other", 2, 2),
+	}
+	versions := func(purge []Build) []string {
+		var v []string
+		for _, b := range purge {
+			v = append(v, b.Group+":"+b.Version)
+		}
+		return v
+	}
+
+	for _, test := range []struct {
+		policy Policy
+		filter Filter
+		want   []string
+	}{
+		{Policy{Keep: 2}, Filter{Include: []string{"com.example"}, Exclude: []string{"*.legacy"}},
+			[]string{"com.example:1.0-20181001.120000-1", "com.example:1.0-20181010.120000-2"}},
+		{Policy{OlderThan: 15 * 24 * time.Hour, Now: now}, Filter{Include: []string{"com.example"}},
+			[]string{"com.example.legacy:1.0-20181001.120000-1", "com.example.legacy:1.0-20181002.120000-2",
+				"com.example:1.0-20181001.120000-1", "com.example:1.0-20181010.120000-2"}},
+		{Policy{Keep: 1, OlderThan: 15 * 24 * time.Hour, Now: now}, Filter{Exclude: []string{"com.example"}},
+			[]string{"org.other:1.0-20181001.120000-1"}},
+		{Policy{}, Filter{}, nil},
+	} {
+		if got := versions(Plan(builds, test.policy, test.filter)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Plan(%+v, %+v) = %v, want %v", test.policy, test.filter, got, test.want)
+		}
+	}
+}
+
+func TestBuilds(t *testing.T) {
+	for _, version := range []int{2, 3} {
+		server := nexustest.NewServer()
+		server.Version = version
+		server.Put("snapshots", "com/example/app/1.0-SNAPSHOT/app-1.0-20181012.101010-3.jar", make([]byte, 100))
+		server.Put("snapshots", "com/example/app/1.0-SNAPSHOT/app-1.0-20181012.101010-3.pom", make([]byte, 20))
+		server.Put("snapshots", "com/example/app/0.9/app-0.9.jar", make([]byte, 90))
+		c, _ := nexus.New(version, nexus.Server{HostURL: server.URL, Username: server.Username, Password: server.Password})
+
+		builds, err := Builds(c, "snapshots")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(builds) != 1 {
+			t.Fatalf("Nexus %d: Builds() = %+v, want 1 build", version, builds)
+		}
+		b := builds[0]
+		if b.GAV() != "com.example:app:1.0-SNAPSHOT" || b.BuildNumber != 3 || b.Size != 120 || len(b.Component.Assets) != 2 {
+			t.Errorf("Nexus %d: build = %+v", version, b)
+		}
+		if err := Delete(c, "snapshots", b); err != nil {
+			t.Fatal(err)
+		}
+		if paths := server.Paths("snapshots"); len(paths) != 1 || paths[0] != "com/example/app/0.9/app-0.9.jar" {
+			t.Errorf("Nexus %d: Delete() left %v", version, paths)
+		}
+		server.Close()
+	}
+}
+
+func TestDeleteNexus2(t *testing.T) {
+	server := nexustest.NewServer()
+	defer server.Close()
+	server.Version = 2
+	dir := "com/example/app/1.0-SNAPSHOT/"
+	for _, name := range []string{
+		"app-1.0-20181010.101010-1.jar", "app-1.0-20181010.101010-1.jar.sha1", "app-1.0-20181010.101010-1.pom",
+		"app-1.0-20181012.101010-2.jar", "app-1.0-20181012.101010-2.pom",
+	} {
+		server.Put("snapshots", dir+name, []byte(name))
+	}
+	server.Put("snapshots", dir+"maven-metadata.xml", []byte("<value>1.0-20181010.101010-1</value><value>1.0-20181012.101010-2</value>"))
+	c, _ := nexus.New(2, nexus.Server{HostURL: server.URL, Username: server.Username, Password: server.Password})
+
+	builds, err := Builds(c, "snapshots")
+	if err != nil || len(builds) != 2 || builds[0].BuildNumber != 1 || len(builds[0].Component.Assets) != 3 {
+		t.Fatalf("Builds() = %+v, %v", builds, err)
+	}
+	if err := Delete(c, "snapshots", builds[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := RebuildMetadata(c, "snapshots", builds[0]); err != nil {
+		t.Fatal(err)
+	}
+	if paths := server.Paths("snapshots"); len(paths) != 3 {
+		t.Errorf("Delete() left %v", paths)
+	}
+	metadata, _ := server.Get("snapshots", dir+"maven-metadata.xml")
+	if strings.Contains(string(metadata), "20181010.101010-1") || !strings.Contains(string(metadata), "20181012.101010-2") {
+		t.Errorf("maven-metadata.xml after the rebuild:\n%s", metadata)
+	}
+}
@@ -0,0 +1,165 @@
+// Package usage adds up the storage used by the assets of repositories.
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Asset is a stored file with its repository, path and size
+type Asset struct {
+	Repository string
+	Path       string
+	Size       int64
+}
+
+// Entry is the storage used by a repository, group, artifact or version
+type Entry struct {
+	Name  string `json:"name" yaml:"name"`
+	Size  int64  `json:"size" yaml:"size"`
+	Files int    `json:"files" yaml:"files"`
+}
+
+// Report is the storage used by repositories, group prefixes, artifacts and versions, largest first.
+// Artifacts are named group:artifact and versions group:artifact:version. Files directly in an artifact
+// directory, such as its maven-metadata.xml, count in their repository and group only. Paths outside
+// the Maven layout only count in their repository and in the group prefix of their directories.
+type Report struct {
+	Date         time.Time `json:"date" yaml:"date"`
+	Size         int64     `json:"size" yaml:"size"`
+	Files        int       `json:"files" yaml:"files"`
+	Repositories []Entry   `json:"repositories" yaml:"repositories"`
+	Groups       []Entry   `json:"groups" yaml:"groups"`
+	Artifacts    []Entry   `json:"artifacts" yaml:"artifacts"`
+	Versions     []Entry   `json:"versions" yaml:"versions"`
+}
+
+// Summarize adds up the sizes of the assets. Groups are cut to their first groupDepth
+// elements, so com.example.app.web counts in com.example with a depth of 2.
+func Summarize(assets []Asset, groupDepth int, date time.Time) *Report {
+	repositories, groups, artifacts, versions := totals{}, totals{}, totals{}, totals{}
+	report := &Report{Date: date}
+	// the artifact directories are found by the <group>/<artifact>/<version>/<artifact>-<version> files
+	artifactDirs := map[string]bool{}
+	for _, a := range assets {
+		parts := strings.Split(strings.Trim(a.Path, "/"), "/")
+		if len(parts) >= 4 && strings.HasPrefix(parts[len(parts)-1], parts[len(parts)-3]+"-") {
+			artifactDirs[a.Repository+":"+strings.Join(parts[:len(parts)-2], "/")] = true
+		}
+	}
+	for _, a := range assets {
+		report.Size += a.Size
+		report.Files++
+		repositories.add(a.Repository, a.Size)
+		parts := strings.Split(strings.Trim(a.Path, "/"), "/")
+		dir := parts[:len(parts)-1]
+		if artifactDirs[a.Repository+":"+strings.Join(dir, "/")] {
+			groups.add(prefix(dir[:len(dir)-1], groupDepth), a.Size)
+			continue
+		}
+		if len(parts) < 4 || !artifactDirs[a.Repository+":"+strings.Join(parts[:len(parts)-2], "/")] {
+			if len(dir) > 0 {
+				groups.add(prefix(dir, groupDepth), a.Size)
+			}
+			continue
+		}
+		group := parts[:len(parts)-3]
+		artifact := strings.Join(group, ".") + ":" + parts[len(parts)-3]
+		groups.add(prefix(group, groupDepth), a.Size)
+		artifacts.add(artifact, a.Size)
+		versions.add(artifact+":"+parts[len(parts)-2], a.Size)
+	}
+	report.Repositories = repositories.sorted()
+	report.Groups = groups.sorted()
+	report.Artifacts = artifacts.sorted()
+	report.Versions = versions.sorted()
+	return report
+}
+
+// prefix joins the first depth path elements with dots
+func prefix(parts []string, depth int) string {
+	if depth > 0 && len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, ".")
+}
+
+// Top returns a copy of the report with the n largest entries of every list, or all of them when n is 0
+func (r *Report) Top(n int) *Report {
+	top := *r
+	top.Repositories = first(r.Repositories, n)
+	top.Groups = first(r.Groups, n)
+	top.Artifacts = first(r.Artifacts, n)
+	top.Versions = first(r.Versions, n)
+	return &top
+}
+
+func first(entries []Entry, n int) []Entry {
+	if n > 0 && len(entries) > n {
+		return entries[:n]
+	}
+	return entries
+}
+
+// WriteCSV writes every entry of the report as a date,level,name,size,files row,
+// so that reports of successive days can be appended to chart the growth
+func (r *Report) WriteCSV(w io.Writer, header bool) error {
+	out := csv.NewWriter(w)
+	if header {
+		if err := out.Write([]string{"date", "level", "name", "size", "files"}); err != nil {
+			return err
+		}
+	}
+	date := r.Date.Format("2006-01-02")
+	for _, level := range []struct {
+		name    string
+		entries []Entry
+	}{
+		{"total", []Entry{{Size: r.Size, Files: r.Files}}},
+		{"repository", r.Repositories},
+		{"group", r.Groups},
+		{"artifact", r.Artifacts},
+		{"version", r.Versions},
+	} {
+		for _, e := range level.entries {
+			row := []string{date, level.name, e.Name, strconv.FormatInt(e.Size, 10), strconv.Itoa(e.Files)}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// totals adds up sizes by name
+type totals map[string]*Entry
+
+func (t totals) add(name string, size int64) {
+	e, found := t[name]
+	if !found {
+		e = &Entry{Name: name}
+		t[name] = e
+	}
+	e.Size += size
+	e.Files++
+}
+
+// sorted returns the entries largest first
+func (t totals) sorted() []Entry {
+	entries := make([]Entry, 0, len(t))
+	for _, e := range t {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
@@ -0,0 +1,48 @@
+package usage
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	assets := []Asset{
+		{Repository: "releases", Path: "com/example/app/web/1.0/web-1.0.war", Size: 100},
+		{Repository: "releases", Path: "com/example/app/web/1.0/web-1.0.pom", Size: 1},
+		{Repository: "releases", Path: "com/example/app/web/1.1/web-1.1.war", Size: 120},
+		{Repository: "releases", Path: "com/example/app/web/maven-metadata.xml", Size: 5},
+		{Repository: "releases", Path: "org/acme/lib/2.0/lib-2.0.jar", Size: 50},
+		{Repository: "site", Path: "/docs/index.html", Size: 10},
+	}
+	date := time.Date(2018, 10, 31, 0, 0, 0, 0, time.UTC)
+	report := Summarize(assets, 2, date)
+	want := &Report{
+		Date:         date,
+		Size:         286,
+		Files:        6,
+		Repositories: []Entry{{"releases", 276, 5}, {"site", 10, 1}},
+		Groups:       []Entry{{"com.example", 226, 4}, {"org.acme", 50, 1}, {"docs", 10, 1}},
+		Artifacts:    []Entry{{"com.example.app:web", 221, 3}, {"org.acme:lib", 50, 1}},
+		Versions:     []Entry{{"com.example.app:web:1.1", 120, 1}, {"com.example.app:web:1.0", 101, 2}, {"org.acme:lib:2.0", 50, 1}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Summarize() = %+v, want %+v", report, want)
+	}
+
+	top := report.Top(1)
+	if len(top.Versions) != 1 || len(report.Versions) != 3 {
+		t.Errorf("Top(1) kept %d versions and left %d in the report", len(top.Versions), len(report.Versions))
+	}
+
+	var buf bytes.Buffer
+	if err := top.WriteCSV(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "date,level,name,size,files" || lines[1] != "2018-10-31,total,,286,6" || lines[5] != "2018-10-31,version,com.example.app:web:1.1,120,1" {
+		t.Errorf("WriteCSV() =\n%s", buf.String())
+	}
+}